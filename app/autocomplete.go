@@ -5,6 +5,7 @@ import (
 	"path"
 	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/md-talim/codecrafters-shell-go/internal/executor"
 )
@@ -61,6 +62,13 @@ func findSharedPrefix(completions []string) string {
 }
 
 func autocomplete(line *string, bellRang bool) AutoCompleteResult {
+	if strings.HasPrefix(*line, "z ") {
+		return autocompleteFrecency(line, bellRang)
+	}
+	if strings.Contains(*line, " ") {
+		return autocompleteFilePath(line, bellRang)
+	}
+
 	var completions []string
 
 	for name := range executor.BuiltinCommands() {
@@ -70,6 +78,15 @@ func autocomplete(line *string, bellRang bool) AutoCompleteResult {
 		}
 	}
 
+	for _, name := range executor.AliasNames() {
+		if strings.HasPrefix(name, *line) {
+			completion := name[len(*line):]
+			if !slices.Contains(completions, completion) {
+				completions = append(completions, completion)
+			}
+		}
+	}
+
 	PATH := os.Getenv("PATH")
 	directories := strings.SplitSeq(PATH, string(os.PathListSeparator))
 
@@ -144,6 +161,119 @@ func autocomplete(line *string, bellRang bool) AutoCompleteResult {
 	return AutoCompleteMore
 }
 
+// autocompleteFrecency completes the keywords given to z with the directory
+// they match, replacing the keywords on the line.
+func autocompleteFrecency(line *string, bellRang bool) AutoCompleteResult {
+	keywordsText := (*line)[len("z "):]
+	matches := executor.FrecencyMatches(strings.Fields(keywordsText))
+
+	if len(matches) == 0 {
+		return AutoCompleteNone
+	}
+
+	if len(matches) == 1 {
+		os.Stdout.WriteString(strings.Repeat("\b \b", utf8.RuneCountInString(keywordsText)))
+		os.Stdout.WriteString(matches[0])
+		*line = "z " + matches[0]
+		return AutoCompleteFound
+	}
+
+	if bellRang {
+		os.Stdout.WriteString("\n")
+		os.Stdout.WriteString(strings.Join(matches, "  "))
+		os.Stdout.WriteString("\n")
+		prompt()
+		os.Stdout.WriteString(*line)
+	}
+
+	return AutoCompleteMore
+}
+
+// autocompleteFilePath completes the last word of the line as a file name.
+// Hidden files are only offered when the word starts with a '.' or dotglob is
+// set, and names are matched regardless of case when nocaseglob is set.
+func autocompleteFilePath(line *string, bellRang bool) AutoCompleteResult {
+	wordStart := strings.LastIndexByte(*line, ' ') + 1
+	directory, prefix := path.Split((*line)[wordStart:])
+
+	readDirectory := directory
+	if readDirectory == "" {
+		readDirectory = "."
+	}
+	entries, err := os.ReadDir(readDirectory)
+	if err != nil {
+		return AutoCompleteNone
+	}
+
+	ignoreCase := executor.ShellOptionEnabled("nocaseglob")
+	var names []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !strings.HasPrefix(prefix, ".") && !executor.ShellOptionEnabled("dotglob") {
+			continue
+		}
+		if len(name) < len(prefix) {
+			continue
+		}
+		if name[:len(prefix)] != prefix && !(ignoreCase && strings.EqualFold(name[:len(prefix)], prefix)) {
+			continue
+		}
+		if stat, err := os.Stat(path.Join(readDirectory, name)); err == nil && stat.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+
+	if len(names) == 0 {
+		return AutoCompleteNone
+	}
+
+	if len(names) == 1 {
+		replaceWord(line, wordStart, directory+names[0])
+		if !strings.HasSuffix(names[0], "/") {
+			os.Stdout.WriteString(" ")
+			*line += " "
+		}
+		return AutoCompleteFound
+	}
+
+	slices.Sort(names)
+	shared := names[0]
+	for _, name := range names[1:] {
+		end := 0
+		for end < len(shared) && end < len(name) && shared[end] == name[end] {
+			end++
+		}
+		// Never stop in the middle of a multibyte character.
+		for end > 0 && end < len(shared) && !utf8.RuneStart(shared[end]) {
+			end--
+		}
+		shared = shared[:end]
+	}
+	if len(shared) > len(prefix) {
+		replaceWord(line, wordStart, directory+shared)
+		return AutoCompleteFound
+	}
+
+	if bellRang {
+		os.Stdout.WriteString("\n")
+		os.Stdout.WriteString(strings.Join(names, "  "))
+		os.Stdout.WriteString("\n")
+		prompt()
+		os.Stdout.WriteString(*line)
+	}
+
+	return AutoCompleteMore
+}
+
+// replaceWord erases the line from wordStart onwards and writes word in its
+// place. The whole word is rewritten since matching may have ignored case.
+func replaceWord(line *string, wordStart int, word string) {
+	os.Stdout.WriteString(strings.Repeat("\b \b", utf8.RuneCountInString((*line)[wordStart:])))
+	os.Stdout.WriteString(word)
+	*line = (*line)[:wordStart] + word
+}
+
 func bell() {
 	os.Stdout.Write([]byte{'\a'})
 }
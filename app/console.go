@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/md-talim/codecrafters-shell-go/internal/executor"
 	"github.com/pkg/term/termios"
@@ -18,19 +19,27 @@ const (
 	ReadResultContent
 )
 
+const primaryPrompt = "$ "
+
+// currentPrompt is the prompt of the line being read, which is redrawn after
+// listing completions or recalling history.
+var currentPrompt = primaryPrompt
+
 func prompt() {
-	os.Stdout.Write([]byte{'$', ' '})
+	os.Stdout.WriteString(currentPrompt)
 }
 
 var historyNavigationIndex int
 
-func read() (string, ReadResult) {
+func read(promptText string) (string, ReadResult) {
+	currentPrompt = promptText
+	historyNavigationIndex = executor.GetHistoryLength()
 	prompt()
 
 	var stdinFd = os.Stdin.Fd()
 	var previous unix.Termios
 	if err := termios.Tcgetattr(stdinFd, &previous); err != nil {
-		fmt.Fprintln(os.Stderr, "Error initializing terminal: ", err)
+		executor.ReportError("cannot initialize terminal: %v", err)
 		return "", ReadResultQuit
 	}
 
@@ -41,7 +50,8 @@ func read() (string, ReadResult) {
 	new.Cc[unix.VMIN] = 1
 	new.Cc[unix.VTIME] = 0
 	if err := termios.Tcsetattr(stdinFd, termios.TCSANOW, &new); err != nil {
-		panic(err)
+		executor.ReportError("cannot initialize terminal: %v", err)
+		return "", ReadResultQuit
 	}
 	defer termios.Tcsetattr(stdinFd, termios.TCSANOW, &previous)
 
@@ -120,8 +130,8 @@ func read() (string, ReadResult) {
 							historyNavigationIndex = targetIndex
 						}
 					} else { // Moving from last history item to the "new command" line
-						currentVisualLength := len(line)
-						fmt.Fprintf(os.Stdout, "\r%s\r", strings.Repeat(" ", len("$ ")+currentVisualLength))
+						currentVisualLength := utf8.RuneCountInString(line)
+						fmt.Fprintf(os.Stdout, "\r%s\r", strings.Repeat(" ", utf8.RuneCountInString(currentPrompt)+currentVisualLength))
 						prompt()
 						line = ""                            // Clear the line buffer
 						historyNavigationIndex = targetIndex // Now at executor.GetHistoryLength()
@@ -132,13 +142,18 @@ func read() (string, ReadResult) {
 			}
 
 		case 0x7f: // BACKSPACE
+			// A multibyte character is removed whole, since it takes up
+			// a single cell on the terminal.
 			if len(line) != 0 {
-				line = line[:len(line)-1]
+				_, size := utf8.DecodeLastRuneInString(line)
+				line = line[:len(line)-size]
 				os.Stdout.Write([]byte{'\b', ' ', '\b'})
 			}
 		default:
 			os.Stdout.Write(buffer)
-			line += string(character)
+			// The byte is kept as it is rather than converted to a rune,
+			// which would mangle the bytes of a multibyte character.
+			line += string(buffer)
 			historyNavigationIndex = executor.GetHistoryLength()
 		}
 	}
@@ -147,8 +162,8 @@ func read() (string, ReadResult) {
 func recallCommandFromHistory(line *string, targetHistoryIndex int) bool {
 	recalledCommand, ok := executor.GetHistoryEntry(targetHistoryIndex)
 	if ok {
-		currentVisualLength := len(*line)
-		fmt.Fprintf(os.Stdout, "\r%s\r", strings.Repeat(" ", len("$ ")+currentVisualLength))
+		currentVisualLength := utf8.RuneCountInString(*line)
+		fmt.Fprintf(os.Stdout, "\r%s\r", strings.Repeat(" ", utf8.RuneCountInString(currentPrompt)+currentVisualLength))
 		prompt()
 		os.Stdout.WriteString(recalledCommand)
 		*line = recalledCommand
@@ -157,3 +172,17 @@ func recallCommandFromHistory(line *string, targetHistoryIndex int) bool {
 	bell()
 	return false
 }
+
+// readContinuationLines reads more lines with the continuation prompt for as
+// long as input is an incomplete command, such as one ending in a backslash,
+// an unclosed quote, a pipe, "&&" or "||".
+func readContinuationLines(input string) string {
+	for executor.NeedsMoreInput(input) {
+		line, result := read(executor.ContinuationPrompt())
+		if result == ReadResultQuit {
+			break
+		}
+		input += "\n" + line
+	}
+	return input
+}
@@ -1,12 +1,38 @@
 package main
 
 import (
+	"os"
+	"strconv"
+	"strings"
+
 	"github.com/md-talim/codecrafters-shell-go/internal/executor"
 )
 
 func main() {
+	args := os.Args[1:]
+	switch {
+	case len(args) >= 2 && args[0] == "--subshell":
+		// A copy of the shell started by the shell itself, for a command
+		// substitution or another part of a command that runs on its own.
+		descriptor, _ := strconv.Atoi(args[1])
+		command := ""
+		if len(args) >= 4 && args[2] == "-c" {
+			command = args[3]
+		}
+		os.Exit(executor.RunSubshell(descriptor, command))
+	case len(args) >= 1 && args[0] == "-c":
+		if len(args) < 2 {
+			executor.ReportError("-c: option requires an argument")
+			os.Exit(2)
+		}
+		os.Exit(executor.RunCommand(args[1]))
+	case len(args) >= 1 && !strings.HasPrefix(args[0], "-"):
+		os.Exit(executor.RunScript(args[0]))
+	}
+
+	executor.StartInteractive()
 	for {
-		input, result := read()
+		input, result := read(primaryPrompt)
 
 		switch result {
 		case ReadResultQuit:
@@ -14,7 +40,7 @@ func main() {
 		case ReadResultEmpty:
 			continue
 		case ReadResultContent:
-			executor.Execute(input)
+			executor.Execute(readContinuationLines(input))
 		}
 	}
 }
@@ -0,0 +1,122 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+type AliasTable struct {
+	aliases map[string]string
+}
+
+func (t *AliasTable) lookup(name string) (string, bool) {
+	value, ok := t.aliases[name]
+	return value, ok
+}
+
+func (t *AliasTable) set(name string, value string) {
+	if t.aliases == nil {
+		t.aliases = make(map[string]string)
+	}
+	t.aliases[name] = value
+}
+
+func (t *AliasTable) remove(name string) bool {
+	if _, ok := t.aliases[name]; !ok {
+		return false
+	}
+	delete(t.aliases, name)
+	return true
+}
+
+func (t *AliasTable) clear() {
+	t.aliases = nil
+}
+
+func (t *AliasTable) names() []string {
+	names := make([]string, 0, len(t.aliases))
+	for name := range t.aliases {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// AliasNames returns the names of all defined aliases in sorted order.
+func AliasNames() []string {
+	return aliases.names()
+}
+
+func aliasCommand(args []string, io shellio.IO) int {
+	if len(args) > 0 && args[0] == "-p" {
+		args = args[1:]
+		if len(args) == 0 {
+			printAliases(io)
+			return 0
+		}
+	}
+	if len(args) == 0 {
+		printAliases(io)
+		return 0
+	}
+
+	status := 0
+	for _, arg := range args {
+		name, value, isDefinition := strings.Cut(arg, "=")
+		if isDefinition {
+			if !isValidAliasName(name) {
+				status = shellError(io, 1, "alias: `%s': invalid alias name", name)
+				continue
+			}
+			aliases.set(name, value)
+			continue
+		}
+
+		if value, ok := aliases.lookup(name); ok {
+			fmt.Fprintf(io.OutputFile(), "alias %s=%s\n", name, singleQuote(value))
+		} else {
+			status = shellError(io, 1, "alias: %s: not found", name)
+		}
+	}
+	return status
+}
+
+func unaliasCommand(args []string, io shellio.IO) int {
+	if len(args) == 0 {
+		return shellError(io, 2, "unalias: usage: unalias [-a] name [name ...]")
+	}
+	if args[0] == "-a" {
+		aliases.clear()
+		return 0
+	}
+
+	status := 0
+	for _, name := range args {
+		if !aliases.remove(name) {
+			status = shellError(io, 1, "unalias: %s: not found", name)
+		}
+	}
+	return status
+}
+
+// printAliases lists every alias in a form that can be read back as input.
+func printAliases(io shellio.IO) {
+	for _, name := range aliases.names() {
+		value, _ := aliases.lookup(name)
+		fmt.Fprintf(io.OutputFile(), "alias %s=%s\n", name, singleQuote(value))
+	}
+}
+
+// isValidAliasName rejects names containing characters that the parser
+// treats specially, since such aliases could never be expanded.
+func isValidAliasName(name string) bool {
+	return len(name) > 0 && !strings.ContainsAny(name, " \t\n'\"\\|&;()<>$`=/")
+}
+
+// singleQuote wraps text in single quotes, escaping embedded single quotes.
+func singleQuote(text string) string {
+	return "'" + strings.ReplaceAll(text, "'", `'\''`) + "'"
+}
@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+// splitAssignments separates the NAME=value words at the start of a command
+// from the words that make up the command itself.
+func splitAssignments(words []parser.Word) ([]parser.Word, []parser.Word) {
+	count := 0
+	for count < len(words) && isAssignmentWord(words[count]) {
+		count++
+	}
+	return words[:count], words[count:]
+}
+
+// isAssignmentWord reports whether a word has the form NAME=value, with the
+// name and the '=' unquoted.
+func isAssignmentWord(word parser.Word) bool {
+	if len(word) == 0 || word[0].Quoting != parser.Unquoted || word[0].Kind != parser.Literal {
+		return false
+	}
+	name, _, found := strings.Cut(word[0].Text, "=")
+	return found && isValidVariableName(name)
+}
+
+// assignVariables performs assignments in the shell itself, as done by a
+// command made only of assignments. The values are expanded but not split
+// into fields or matched against file names.
+func assignVariables(assignments []parser.Word, substitutions *processSubstitutions) error {
+	for _, assignment := range assignments {
+		expanded, err := expandWord(assignment, substitutions)
+		if err != nil {
+			return err
+		}
+		name, value, _ := strings.Cut(expanded, "=")
+		variables.set(name, value)
+	}
+	return nil
+}
+
+// exportTemporarily places assignments in the environment for the duration
+// of a single command. The returned function restores the previous values.
+func exportTemporarily(assignments []parser.Word, substitutions *processSubstitutions) (func(), error) {
+	var saved []savedVariable
+	restore := func() {
+		for index := len(saved) - 1; index >= 0; index-- {
+			variables.restore(saved[index])
+		}
+	}
+
+	for _, assignment := range assignments {
+		expanded, err := expandWord(assignment, substitutions)
+		if err != nil {
+			restore()
+			return nil, err
+		}
+		name, value, _ := strings.Cut(expanded, "=")
+		saved = append(saved, variables.save(name))
+		variables.export(name, value)
+	}
+	return restore, nil
+}
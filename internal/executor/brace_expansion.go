@@ -0,0 +1,198 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+// expandBraces performs brace expansion on a word and returns the words it
+// expands to, in order. Quoted braces and commas are taken literally, and
+// alternatives that leave nothing at all, as in "{,}", are dropped.
+func expandBraces(word parser.Word) []parser.Word {
+	var words []parser.Word
+	for _, units := range expandBraceUnits(splitWordUnits(word)) {
+		if len(units) == 0 {
+			continue
+		}
+		words = append(words, joinWordUnits(units))
+	}
+	return words
+}
+
+// splitWordUnits splits every literal part of a word into single-byte parts,
+// so that braces and commas can be matched while keeping track of their
+// quoting. Expansions are kept whole.
+func splitWordUnits(word parser.Word) []parser.WordPart {
+	var units []parser.WordPart
+	for _, part := range word {
+		if len(part.Text) == 0 || part.Kind != parser.Literal {
+			units = append(units, part)
+			continue
+		}
+		for index := 0; index < len(part.Text); index++ {
+			units = append(units, parser.WordPart{Text: part.Text[index : index+1], Quoting: part.Quoting})
+		}
+	}
+	return units
+}
+
+// joinWordUnits merges adjacent units that were quoted the same way back into
+// the parts of a word.
+func joinWordUnits(units []parser.WordPart) parser.Word {
+	var word parser.Word
+	for _, unit := range units {
+		last := len(word) - 1
+		if last >= 0 && word[last].Quoting == unit.Quoting && word[last].Kind == parser.Literal && unit.Kind == parser.Literal {
+			word[last].Text += unit.Text
+			continue
+		}
+		word = append(word, unit)
+	}
+	return word
+}
+
+func isUnquotedUnit(unit parser.WordPart, character string) bool {
+	return unit.Quoting == parser.Unquoted && unit.Kind == parser.Literal && unit.Text == character
+}
+
+// expandBraceUnits expands the first valid brace expression of units, then
+// recursively the alternatives and the text that follows it.
+func expandBraceUnits(units []parser.WordPart) [][]parser.WordPart {
+	for open := 0; open < len(units); open++ {
+		if !isUnquotedUnit(units[open], "{") || (open > 0 && isUnquotedUnit(units[open-1], "$")) {
+			continue
+		}
+		close, commas := findBraceClose(units, open)
+		if close < 0 {
+			continue
+		}
+
+		var alternatives [][]parser.WordPart
+		if len(commas) > 0 {
+			start := open + 1
+			for _, comma := range append(commas, close) {
+				alternatives = append(alternatives, expandBraceUnits(units[start:comma])...)
+				start = comma + 1
+			}
+		} else if sequence, ok := expandBraceSequence(units[open+1 : close]); ok {
+			for _, element := range sequence {
+				alternatives = append(alternatives, []parser.WordPart{{Text: element, Quoting: parser.Unquoted}})
+			}
+		} else {
+			continue
+		}
+
+		preamble := units[:open]
+		var results [][]parser.WordPart
+		for _, alternative := range alternatives {
+			for _, postscript := range expandBraceUnits(units[close+1:]) {
+				result := make([]parser.WordPart, 0, len(preamble)+len(alternative)+len(postscript))
+				result = append(result, preamble...)
+				result = append(result, alternative...)
+				result = append(result, postscript...)
+				results = append(results, result)
+			}
+		}
+		return results
+	}
+	return [][]parser.WordPart{units}
+}
+
+// findBraceClose returns the index of the brace that closes the one at open,
+// along with the positions of the unquoted commas directly inside it. It
+// returns -1 if the brace is never closed.
+func findBraceClose(units []parser.WordPart, open int) (int, []int) {
+	var commas []int
+	depth := 0
+	for index := open; index < len(units); index++ {
+		switch {
+		case isUnquotedUnit(units[index], "{"):
+			depth++
+		case isUnquotedUnit(units[index], "}"):
+			depth--
+			if depth == 0 {
+				return index, commas
+			}
+		case isUnquotedUnit(units[index], ",") && depth == 1:
+			commas = append(commas, index)
+		}
+	}
+	return -1, nil
+}
+
+// expandBraceSequence expands a sequence expression such as 1..10, a..e,
+// 0..20..5 or 01..10, whose elements are zero-padded to the same width.
+func expandBraceSequence(units []parser.WordPart) ([]string, bool) {
+	for _, unit := range units {
+		if unit.Quoting != parser.Unquoted || unit.Kind != parser.Literal {
+			return nil, false
+		}
+	}
+	text := joinWordUnits(units).String()
+
+	bounds := strings.Split(text, "..")
+	if len(bounds) != 2 && len(bounds) != 3 {
+		return nil, false
+	}
+
+	increment := 1
+	if len(bounds) == 3 {
+		value, err := strconv.Atoi(bounds[2])
+		if err != nil {
+			return nil, false
+		}
+		increment = max(value, -value, 1)
+	}
+
+	start, startErr := strconv.Atoi(bounds[0])
+	end, endErr := strconv.Atoi(bounds[1])
+	if startErr == nil && endErr == nil {
+		width := 0
+		if hasLeadingZero(bounds[0]) || hasLeadingZero(bounds[1]) {
+			width = max(len(bounds[0]), len(bounds[1]))
+		}
+		var sequence []string
+		for _, value := range braceRange(start, end, increment) {
+			sequence = append(sequence, fmt.Sprintf("%0*d", width, value))
+		}
+		return sequence, true
+	}
+
+	if len(bounds[0]) == 1 && len(bounds[1]) == 1 && isLetter(bounds[0][0]) && isLetter(bounds[1][0]) {
+		var sequence []string
+		for _, value := range braceRange(int(bounds[0][0]), int(bounds[1][0]), increment) {
+			sequence = append(sequence, string(byte(value)))
+		}
+		return sequence, true
+	}
+
+	return nil, false
+}
+
+// braceRange returns the values from start to end, inclusive, stepping by
+// increment in whichever direction end lies.
+func braceRange(start int, end int, increment int) []int {
+	var values []int
+	if start <= end {
+		for value := start; value <= end; value += increment {
+			values = append(values, value)
+		}
+	} else {
+		for value := start; value >= end; value -= increment {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+func hasLeadingZero(number string) bool {
+	number = strings.TrimPrefix(number, "-")
+	return len(number) > 1 && number[0] == '0'
+}
+
+func isLetter(character byte) bool {
+	return (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z')
+}
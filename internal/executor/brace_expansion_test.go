@@ -0,0 +1,89 @@
+package executor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+func unquotedWord(text string) parser.Word {
+	return parser.Word{{Text: text, Quoting: parser.Unquoted}}
+}
+
+func wordStrings(words []parser.Word) []string {
+	var texts []string
+	for _, word := range words {
+		texts = append(texts, word.String())
+	}
+	return texts
+}
+
+func TestExpandBraces(t *testing.T) {
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"plain", []string{"plain"}},
+		{"a{b,c}d", []string{"abd", "acd"}},
+		{"src/{cmd,internal,pkg}", []string{"src/cmd", "src/internal", "src/pkg"}},
+		{"{x,y{1,2}}", []string{"x", "y1", "y2"}},
+		{"{1..3}{a,b}", []string{"1a", "1b", "2a", "2b", "3a", "3b"}},
+		{"{1..10..3}", []string{"1", "4", "7", "10"}},
+		{"{3..1}", []string{"3", "2", "1"}},
+		{"{05..1..2}", []string{"05", "03", "01"}},
+		{"{a..e}", []string{"a", "b", "c", "d", "e"}},
+		{"{z..x}", []string{"z", "y", "x"}},
+		{"a{,}b", []string{"ab", "ab"}},
+		{"{,}", nil},
+		{"{a}", []string{"{a}"}},
+		{"{a,b", []string{"{a,b"}},
+		{"{1..a}", []string{"{1..a}"}},
+	}
+	for _, test := range tests {
+		got := wordStrings(expandBraces(unquotedWord(test.word)))
+		if !slices.Equal(got, test.want) {
+			t.Errorf("expandBraces(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestExpandBracesLeavesQuotedBracesLiteral(t *testing.T) {
+	tests := []struct {
+		name string
+		word parser.Word
+		want []string
+	}{
+		{
+			name: "quoted braces",
+			word: parser.Word{{Text: "{a,b}", Quoting: parser.DoubleQuoted}},
+			want: []string{"{a,b}"},
+		},
+		{
+			name: "escaped comma",
+			word: parser.Word{
+				{Text: "{a,b", Quoting: parser.Unquoted},
+				{Text: ",", Quoting: parser.SingleQuoted},
+				{Text: "c}", Quoting: parser.Unquoted},
+			},
+			want: []string{"a", "b,c"},
+		},
+		{
+			name: "quoted alternative",
+			word: parser.Word{
+				{Text: "{", Quoting: parser.Unquoted},
+				{Text: "a b", Quoting: parser.SingleQuoted},
+				{Text: ",c}", Quoting: parser.Unquoted},
+			},
+			want: []string{"a b", "c"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := expandBraces(test.word)
+			if texts := wordStrings(got); !slices.Equal(texts, test.want) {
+				t.Errorf("expandBraces = %q, want %q", texts, test.want)
+			}
+		})
+	}
+}
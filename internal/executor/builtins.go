@@ -3,30 +3,70 @@ package executor
 import (
 	"fmt"
 	"os"
-	"path"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
 )
 
-type BuiltinCommandExecutor func([]string, shellio.IO)
+// BuiltinCommandExecutor runs a builtin command and returns its exit status.
+type BuiltinCommandExecutor func([]string, shellio.IO) int
 type BuiltinCommandsMap map[string]BuiltinCommandExecutor
 
 var builtinCommands BuiltinCommandsMap
 var history CommandHistory
+var variables ShellVariables
+var aliases AliasTable
+var directoryStack DirectoryStack
+var jobs JobTable
+var hashedCommands HashTable
+
+// shellFiles holds the files the shell's own descriptors refer to. Every
+// command starts from them, and exec changes them for good.
+var shellFiles shellio.FileRedirect
 
 func init() {
-	loadHistoryFromHISTFILE()
 	builtinCommands = BuiltinCommandsMap{
+		".":       sourceCommand,
+		"alias":   aliasCommand,
+		"builtin": builtinCommand,
 		"cd":      cdCommand,
+		"command": commandCommand,
+		"dirs":    dirsCommand,
 		"echo":    echoCommand,
+		"eval":    evalCommand,
+		"exec":    execCommand,
 		"exit":    exitCommand,
+		"hash":    hashCommand,
 		"history": historyCommand,
+		"jobs":    jobsCommand,
+		"popd":    popdCommand,
+		"printf":  printfCommand,
+		"pushd":   pushdCommand,
 		"pwd":     pwdCommand,
+		"read":    readCommand,
+		"shopt":   shoptCommand,
+		"source":  sourceCommand,
 		"type":    typeCommand,
+		"unalias": unaliasCommand,
+		"wait":    waitCommand,
+		"z":       zCommand,
 	}
 }
 
+// isInteractive is set when the shell reads its commands from the prompt.
+var isInteractive bool
+
+// StartInteractive prepares the shell for reading commands at the prompt. It
+// runs the startup files and loads the history from $HISTFILE, which is
+// saved again on exit.
+func StartInteractive() {
+	isInteractive = true
+	loadStartupFiles()
+	loadHistoryFromHISTFILE()
+}
+
 func BuiltinCommands() BuiltinCommandsMap {
 	return builtinCommands
 }
@@ -42,82 +82,168 @@ func GetHistoryEntry(index int) (string, bool) {
 	return "", false
 }
 
-func exitCommand(_ []string, _ shellio.IO) {
-	writeHistoryToHISTFILE()
-	os.Exit(0)
-}
-
-func echoCommand(args []string, io shellio.IO) {
-	output := strings.Join(args, " ")
-	fmt.Fprintln(io.OutputFile(), output)
+// exitCommand ends the shell with the given status, or with that of the
+// last command.
+func exitCommand(args []string, io shellio.IO) int {
+	status := lastExitStatus
+	if len(args) > 0 {
+		value, err := strconv.Atoi(args[0])
+		if err != nil {
+			status = shellError(io, 2, "exit: %s: numeric argument required", args[0])
+		} else {
+			status = value & 0xff
+		}
+	}
+	if isInteractive {
+		writeHistoryToHISTFILE()
+	}
+	os.Exit(status)
+	return status
 }
 
-func typeCommand(args []string, io shellio.IO) {
-	if len(args) < 1 {
-		fmt.Println("type: missing operand")
-		return
-	}
-
-	for _, arg := range args {
-		switch arg {
-		case "exit", "echo", "type", "pwd", "cd", "history":
-			fmt.Fprintf(io.OutputFile(), "%s is a shell builtin\n", arg)
-		default:
-			if path, ok := findPath(arg); ok {
-				fmt.Fprintf(io.OutputFile(), "%s is %s\n", arg, path)
-			} else {
-				fmt.Fprintf(io.OutputFile(), "%s: not found\n", arg)
+func echoCommand(args []string, io shellio.IO) int {
+	printNewline := true
+	interpretEscapes := false
+
+	// Options are only recognized while every character is a valid flag, so
+	// arguments like "-x" or "-" are printed as they are.
+	for len(args) > 0 && isEchoOption(args[0]) {
+		for _, flag := range args[0][1:] {
+			switch flag {
+			case 'n':
+				printNewline = false
+			case 'e':
+				interpretEscapes = true
+			case 'E':
+				interpretEscapes = false
 			}
 		}
+		args = args[1:]
 	}
+
+	output := strings.Join(args, " ")
+	if interpretEscapes {
+		var stopped bool
+		output, stopped = expandEchoEscapes(output)
+		if stopped {
+			printNewline = false
+		}
+	}
+	if printNewline {
+		output += "\n"
+	}
+	io.OutputFile().WriteString(output)
+	return 0
 }
 
-func pwdCommand(_ []string, io shellio.IO) {
-	dir, err := os.Getwd()
+func pwdCommand(args []string, io shellio.IO) int {
+	options, _, err := parseBuiltinOptions("pwd", args, "LP")
 	if err != nil {
-		fmt.Println("Error: ", err)
+		return usageError(io, err, "pwd: usage: pwd [-LP]")
+	}
+
+	isPhysical := false
+	for _, option := range options {
+		isPhysical = option.flag == 'P'
+	}
+
+	dir := logicalWorkingDirectory()
+	if isPhysical {
+		dir, err = syscall.Getwd()
+		if err != nil {
+			return shellError(io, 1, "pwd: %s", describeFileError(err))
+		}
 	}
 	fmt.Fprintln(io.OutputFile(), dir)
+	return 0
 }
 
-func cdCommand(args []string, io shellio.IO) {
-	newDir := args[0]
+func cdCommand(args []string, io shellio.IO) int {
+	options, operands, err := parseBuiltinOptions("cd", args, "LP")
+	if err != nil {
+		return usageError(io, err, "cd: usage: cd [-L|-P] [dir]")
+	}
+	if len(operands) > 1 {
+		return shellError(io, 1, "cd: too many arguments")
+	}
 
-	if strings.HasPrefix(newDir, "~") {
-		HOME := os.Getenv("HOME")
-		if (len(HOME)) == 0 {
-			fmt.Fprintln(io.ErrorFile(), "cd: $HOME is not set.")
-		} else {
-			newDir = path.Join(HOME, newDir[1:])
+	isPhysical := false
+	for _, option := range options {
+		isPhysical = option.flag == 'P'
+	}
+
+	var newDir string
+	shouldPrintDirectory := false
+	if len(operands) == 0 {
+		HOME, _ := variables.get("HOME")
+		if len(HOME) == 0 {
+			return shellError(io, 1, "cd: HOME not set")
 		}
+		newDir = HOME
+	} else if operands[0] == "-" {
+		OLDPWD, _ := variables.get("OLDPWD")
+		if len(OLDPWD) == 0 {
+			return shellError(io, 1, "cd: OLDPWD not set")
+		}
+		newDir = OLDPWD
+		shouldPrintDirectory = true
+	} else {
+		newDir = operands[0]
 	}
 
-	if err := os.Chdir(newDir); err != nil {
-		fmt.Fprintf(io.ErrorFile(), "cd: %s: No such file or directory\n", newDir)
+	if len(newDir) == 0 {
+		return 0
 	}
+
+	target, isFromCDPATH := searchCDPATH(newDir)
+	if err := changeDirectory(target, isPhysical); err != nil {
+		return shellError(io, 1, "cd: %s: %s", newDir, describeFileError(err))
+	}
+	recordDirectoryVisit(logicalWorkingDirectory())
+
+	if shouldPrintDirectory || isFromCDPATH {
+		fmt.Fprintln(io.OutputFile(), logicalWorkingDirectory())
+	}
+	return 0
 }
 
-func historyCommand(args []string, io shellio.IO) {
+// historyUsage is how the history builtin is used, shown with usage errors.
+const historyUsage = "history: usage: history [n] | history -r|-w|-a [filename]"
+
+func historyCommand(args []string, io shellio.IO) int {
 	if len(args) == 0 {
 		history.printAll(io)
-		return
+		return 0
 	}
 
 	// The first arg can be the action like "-r", "-w", or "-a"
 	// It can also be the limit for history
 	action := args[0]
+	fileName := ""
+	if action == "-r" || action == "-w" || action == "-a" {
+		// Without a file name, the actions use the history file.
+		if len(args) > 1 {
+			fileName = args[1]
+		} else if fileName, _ = variables.get("HISTFILE"); fileName == "" {
+			return usageError(io, fmt.Errorf("history: %s: no file name given and HISTFILE is not set", action), historyUsage)
+		}
+	}
+	var err error
 	if action == "-r" {
-		history.appendFromFile(args[1])
+		err = history.appendFromFile(fileName)
 	} else if action == "-w" {
-		history.saveToFile(args[1])
+		err = history.saveToFile(fileName)
 	} else if action == "-a" {
-		history.appendToFile(args[1])
+		err = history.appendToFile(fileName)
 	} else {
-		limit, err := parseHistoryLimit(action)
-		if err != nil {
-			fmt.Fprintln(io.ErrorFile(), err)
-			return
+		limit, limitErr := parseHistoryLimit(action)
+		if limitErr != nil {
+			return shellError(io, 1, "history: %v", limitErr)
 		}
 		history.printLast(limit, io)
 	}
+	if err != nil {
+		return shellError(io, 1, "history: %s: %s", fileName, describeFileError(err))
+	}
+	return 0
 }
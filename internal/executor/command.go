@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// unwrapCommand removes the leading "command" and "builtin" words, which
+// only change how the command after them is looked up. It reports whether a
+// "builtin" was among them, in which case the command must be a builtin.
+// Since the shell has no functions, "command" only bypasses aliases, which
+// the parser never expands after it anyway. A "command" with options is a
+// builtin of its own and is left in place.
+func unwrapCommand(args []string) ([]string, bool) {
+	isBuiltinRequired := false
+	for len(args) > 0 {
+		switch args[0] {
+		case "command":
+			if len(args) > 1 && args[1] == "--" {
+				args = args[2:]
+				continue
+			}
+			if len(args) > 1 && strings.HasPrefix(args[1], "-") {
+				return args, isBuiltinRequired
+			}
+			args = args[1:]
+		case "builtin":
+			args = args[1:]
+			isBuiltinRequired = true
+		default:
+			return args, isBuiltinRequired
+		}
+	}
+	return args, isBuiltinRequired
+}
+
+// commandCommand handles "command" with options, as the dispatch unwraps
+// every other use. With -v it prints how each name would be run, and with -V
+// describes it like type. With -p the command is looked up in a default
+// $PATH that finds the standard utilities.
+func commandCommand(args []string, io shellio.IO) int {
+	options, operands, err := parseBuiltinOptions("command", args, "pvV")
+	if err != nil {
+		return usageError(io, err, "command: usage: command [-pVv] command [arg ...]")
+	}
+
+	isDefaultPath, isShort, isVerbose := false, false, false
+	for _, option := range options {
+		switch option.flag {
+		case 'p':
+			isDefaultPath = true
+		case 'v':
+			isShort = true
+		case 'V':
+			isVerbose = true
+		}
+	}
+	if len(operands) == 0 {
+		return 0
+	}
+
+	// A name containing a slash is never looked up, so -p does not apply.
+	searchesDefaultPath := func(name string) bool {
+		return isDefaultPath && !strings.Contains(name, "/")
+	}
+
+	switch {
+	case isVerbose:
+		return typeCommand(operands, io)
+	case isShort:
+		status := 0
+		for _, name := range operands {
+			matches := lookupCommand(name, false, false)
+			if searchesDefaultPath(name) && (len(matches) == 0 || matches[0].kind == "file") {
+				matches = nil
+				if path, ok := findPathIn(name, defaultPath); ok {
+					matches = []commandMatch{{kind: "file", value: path}}
+				}
+			}
+			if len(matches) == 0 {
+				status = 1
+				continue
+			}
+			switch match := matches[0]; match.kind {
+			case "alias":
+				fmt.Fprintf(io.OutputFile(), "alias %s=%s\n", name, singleQuote(match.value))
+			case "file":
+				fmt.Fprintln(io.OutputFile(), match.value)
+			default:
+				fmt.Fprintln(io.OutputFile(), name)
+			}
+		}
+		return status
+	}
+
+	name, commandArgs := operands[0], operands[1:]
+	if builtin, ok := builtinCommands[name]; ok {
+		return builtin(commandArgs, io)
+	}
+	if searchesDefaultPath(name) {
+		path, ok := findPathIn(name, defaultPath)
+		if !ok {
+			return shellError(io, 127, "command: %s: not found", name)
+		}
+		return executeExternalCommand(path, operands, io, inheritedFiles(io))
+	}
+	path, status, err := findCommand(name)
+	if err != nil {
+		return shellError(io, status, "command: %s: %v", name, err)
+	}
+	return executeExternalCommand(path, operands, io, inheritedFiles(io))
+}
+
+// defaultPath is searched instead of $PATH by "command -p".
+const defaultPath = "/usr/bin:/bin:/usr/sbin:/sbin"
+
+// builtinCommand runs a builtin even where another command of the same name
+// would be found first. The dispatch handles it too; this is only reached
+// with no command at all.
+func builtinCommand(args []string, io shellio.IO) int {
+	if len(args) == 0 {
+		return 0
+	}
+	builtin, ok := builtinCommands[args[0]]
+	if !ok {
+		return shellError(io, 1, "builtin: %s: not a shell builtin", args[0])
+	}
+	return builtin(args[1:], io)
+}
@@ -1,58 +1,245 @@
 package executor
 
 import (
-	"fmt"
+	"errors"
+	"os"
 	"os/exec"
+	"slices"
+	"syscall"
 
 	"github.com/md-talim/codecrafters-shell-go/internal/parser"
 	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
 )
 
+// lastExitStatus is the exit status of the most recently executed command.
+var lastExitStatus int
+
+// Execute runs a line entered at the prompt and records it in the history.
 func Execute(input string) {
 	history.add(input)
+	if err := executeLine(input, &shellFiles); err != nil {
+		reportLineError(&shellFiles, err)
+	}
+}
+
+// RunCommand runs a command line given to the shell with -c and returns the
+// exit status of its last command.
+func RunCommand(command string) int {
+	if err := executeLine(command, &shellFiles); err != nil {
+		reportLineError(&shellFiles, err)
+	}
+	return lastExitStatus
+}
+
+// RunScript runs the commands of a file given as an argument to the shell
+// and returns the exit status of the last one.
+func RunScript(fileName string) int {
+	status, err := sourceFile(fileName, &shellFiles)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return shellError(&shellFiles, 127, "%s: %s", fileName, describeFileError(err))
+		}
+		return shellError(&shellFiles, 126, "%s: %s", fileName, describeFileError(err))
+	}
+	return status
+}
+
+// NeedsMoreInput reports whether input ends in the middle of a command, for
+// example inside quotes, after a backslash-newline or after a pipe or "&&",
+// so that the rest of the command can be read from the next line.
+func NeedsMoreInput(input string) bool {
 	p := parser.NewParser(input)
-	parsedCommands, redirectionConfig := p.Parse()
+	p.ResolveAlias = aliases.lookup
+	p.ExtendedPatterns = shellOptions.isEnabled("extglob")
+	_, err := p.Parse()
+	return parser.IsIncomplete(err)
+}
 
-	if len(parsedCommands) == 0 {
-		return
+// ContinuationPrompt returns the prompt shown while reading the rest of an
+// incomplete command, which is $PS2 if set.
+func ContinuationPrompt() string {
+	if PS2, ok := variables.get("PS2"); ok {
+		return PS2
 	}
+	return "> "
+}
 
-	finalIO, isRedirected := shellio.OpenIo(redirectionConfig)
-	if isRedirected {
-		defer finalIO.Close()
+// executeLine parses and runs a line of input in the current shell, with
+// parentIO as the streams the commands start from. The line may hold several
+// and-or lists separated by ';' or newlines, which run one after another.
+// Syntax errors are returned to the caller, which knows where the line came
+// from and how to report them; nothing is run in that case.
+func executeLine(input string, parentIO shellio.IO) error {
+	p := parser.NewParser(input)
+	p.ResolveAlias = aliases.lookup
+	p.ExtendedPatterns = shellOptions.isEnabled("extglob")
+	lists, err := p.Parse()
+	if err != nil {
+		lastExitStatus = 2
+		return err
+	}
+
+	for _, list := range lists {
+		executeAndOrList(list, parentIO)
 	}
+	return nil
+}
 
-	if len(parsedCommands) == 1 {
-		executeSingleCommand(parsedCommands[0], finalIO)
-	} else {
-		executePipelines(parsedCommands, finalIO)
+// executeAndOrList runs the pipelines of list one after another, skipping
+// those whose operator does not match the exit status so far.
+func executeAndOrList(list parser.AndOrList, parentIO shellio.IO) {
+	for index, pipeline := range list.Pipelines {
+		if index > 0 && (list.Operators[index-1] == "&&") != (lastExitStatus == 0) {
+			continue
+		}
+		switch {
+		case pipeline[0].Coprocess != nil:
+			lastExitStatus = startCoprocess(pipeline[0], parentIO)
+		case len(pipeline) == 1:
+			lastExitStatus = executeSingleCommand(pipeline[0], parentIO)
+		default:
+			lastExitStatus = executePipelines(pipeline, parentIO)
+		}
 	}
 }
 
-func executeSingleCommand(command []string, finalShellIO shellio.IO) {
-	if len(command) == 0 {
-		return
+func executeSingleCommand(command parser.Command, parentIO shellio.IO) int {
+	substitutionStatus = 0
+	var substitutions processSubstitutions
+	defer substitutions.finish()
+
+	assignments, words := splitAssignments(command.Args)
+	args, err := expandWords(words, &substitutions)
+	if err != nil {
+		return shellError(parentIO, 1, "%v", err)
 	}
-	commandName := command[0]
-	commandArgs := command[1:]
+
+	redirections, err := expandRedirections(command.Redirections, &substitutions)
+	if err != nil {
+		return shellError(parentIO, 1, "%v", err)
+	}
+	commandIO, err := shellio.OpenIo(redirections, parentIO)
+	if err != nil {
+		return shellError(parentIO, 1, "%s", describeFileError(err))
+	}
+	defer commandIO.Close()
+
+	// Without a command, assignments apply to the shell itself.
+	if len(args) == 0 {
+		if err := assignVariables(assignments, &substitutions); err != nil {
+			return shellError(commandIO, 1, "%v", err)
+		}
+		return substitutionStatus
+	}
+
+	restore, err := exportTemporarily(assignments, &substitutions)
+	if err != nil {
+		return shellError(commandIO, 1, "%v", err)
+	}
+	defer restore()
+
+	args, isBuiltinRequired := unwrapCommand(args)
+	if len(args) == 0 {
+		return 0
+	}
+	commandName := args[0]
+	commandArgs := args[1:]
 
 	if builtinCommandExecutor, isBuiltinCommand := builtinCommands[commandName]; isBuiltinCommand {
-		builtinCommandExecutor(commandArgs, finalShellIO)
-	} else if _, ok := findPath(commandName); ok {
-		executeExternalCommand(commandName, commandArgs, finalShellIO)
-	} else {
-		fmt.Fprintf(finalShellIO.OutputFile(), "%s: command not found\n", commandName)
+		return builtinCommandExecutor(commandArgs, commandIO)
+	} else if isBuiltinRequired {
+		return shellError(commandIO, 1, "builtin: %s: not a shell builtin", commandName)
+	}
+
+	path, status, err := findCommand(commandName)
+	if err != nil {
+		return shellError(commandIO, status, "%s: %v", commandName, err)
+	}
+	return executeExternalCommand(path, args, commandIO, substitutions.extraFiles(commandIO))
+}
+
+// inheritedFiles returns the files on descriptors 3 and above that commands
+// started with io inherit, indexed from descriptor 3 as exec.Cmd.ExtraFiles
+// expects. The descriptors the shell holds for coprocesses are left out.
+func inheritedFiles(io shellio.IO) []*os.File {
+	var files []*os.File
+	for _, descriptor := range io.Descriptors() {
+		file := io.File(descriptor)
+		if descriptor < 3 || jobs.holds(file) {
+			continue
+		}
+		for len(files) <= descriptor-3 {
+			files = append(files, nil)
+		}
+		files[descriptor-3] = file
+	}
+	return files
+}
+
+// followShellFiles returns an IO for running the commands of a file or of
+// eval, whose descriptors follow the shell's own as exec changes them, except
+// for those io sets differently, such as by the redirections of the command
+// that runs them or the pipes of its pipeline.
+func followShellFiles(io shellio.IO) shellio.IO {
+	descriptors := slices.Concat([]int{0, 1, 2}, io.Descriptors(), shellFiles.Descriptors())
+	ownFiles := make(map[int]*os.File)
+	for _, descriptor := range descriptors {
+		if file := io.File(descriptor); file != shellFiles.File(descriptor) {
+			ownFiles[descriptor] = file
+		}
 	}
+	return shellio.Overlay(&shellFiles, ownFiles)
 }
 
-func executeExternalCommand(command string, args []string, io shellio.IO) {
-	cmd := exec.Command(command, args...)
+// executeExternalCommand runs the program at path with args, whose first
+// element is the name it was run by, and waits for it to exit.
+func executeExternalCommand(path string, args []string, io shellio.IO, extraFiles []*os.File) int {
+	cmd, err := startExternalCommand(path, args, io, extraFiles)
+	if err != nil {
+		return shellError(io, 126, "%s: %s", args[0], describeFileError(err))
+	}
+	return exitStatusOf(cmd.Wait())
+}
+
+// newExternalCommand prepares the program at path to run with the files of
+// io. The path is used as it is, without searching $PATH again.
+func newExternalCommand(path string, args []string, io shellio.IO, extraFiles []*os.File) *exec.Cmd {
+	cmd := &exec.Cmd{Path: path, Args: args}
+	cmd.Stdin = io.InputFile()
 	cmd.Stdout = io.OutputFile()
 	cmd.Stderr = io.ErrorFile()
-	cmd.Run()
+	cmd.ExtraFiles = extraFiles
+	return cmd
 }
 
-func executePipelines(parsedCommands [][]string, finalShellIO shellio.IO) {
-	pipelineRunner := newPipelineRunner(parsedCommands, finalShellIO)
-	pipelineRunner.run()
+// startExternalCommand starts the program at path like newExternalCommand
+// prepares it. A file the system cannot run, such as a script without a "#!"
+// line, is run by a new copy of the shell instead, as other shells do.
+func startExternalCommand(path string, args []string, io shellio.IO, extraFiles []*os.File) (*exec.Cmd, error) {
+	cmd := newExternalCommand(path, args, io, extraFiles)
+	err := cmd.Start()
+	if !errors.Is(err, syscall.ENOEXEC) {
+		return cmd, err
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	cmd = newExternalCommand(executable, shellScriptArgs(path, args), io, extraFiles)
+	return cmd, cmd.Start()
+}
+
+// shellScriptArgs returns the arguments that make a copy of the shell run the
+// script at path, which was run with args.
+func shellScriptArgs(path string, args []string) []string {
+	return slices.Concat([]string{os.Args[0], path}, args[1:])
+}
+
+func executePipelines(parsedCommands []parser.Command, parentIO shellio.IO) int {
+	pipelineRunner := newPipelineRunner(parsedCommands, parentIO)
+	if pipelineRunner == nil {
+		return 1
+	}
+	return pipelineRunner.run()
 }
@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// startCoprocess starts the body of a coproc command with pipes connected to
+// its input and output, and adds it to the job table without waiting for it.
+// The array NAME holds the descriptor to read its output from and the one to
+// write its input to, and NAME_PID the process ID of the subshell the body
+// runs in, so the body cannot change the shell's variables or other state.
+func startCoprocess(command parser.Command, parentIO shellio.IO) int {
+	coprocess := command.Coprocess
+	if !isValidVariableName(coprocess.Name) {
+		return shellError(parentIO, 1, "coproc: `%s': not a valid identifier", coprocess.Name)
+	}
+
+	var substitutions processSubstitutions
+	redirections, err := expandRedirections(command.Redirections, &substitutions)
+	if err != nil {
+		shellError(parentIO, 1, "%v", err)
+		substitutions.finish()
+		return 1
+	}
+
+	inputReader, inputWriter, err := os.Pipe()
+	if err != nil {
+		shellError(parentIO, 1, "coproc: cannot make pipe: %v", err)
+		substitutions.finish()
+		return 1
+	}
+	outputReader, outputWriter, err := os.Pipe()
+	if err != nil {
+		shellError(parentIO, 1, "coproc: cannot make pipe: %v", err)
+		inputReader.Close()
+		inputWriter.Close()
+		substitutions.finish()
+		return 1
+	}
+
+	pipeIO := shellio.WithFile(shellio.WithFile(parentIO, 0, inputReader), 1, outputWriter)
+	bodyIO, err := shellio.OpenIo(redirections, pipeIO)
+	if err != nil {
+		shellError(parentIO, 1, "%s", describeFileError(err))
+		for _, file := range []*os.File{inputReader, inputWriter, outputReader, outputWriter} {
+			file.Close()
+		}
+		substitutions.finish()
+		return 1
+	}
+
+	// The body was parsed with aliases expanded, which must not be expanded
+	// again in the subshell.
+	state := captureSubshellState()
+	state.Aliases = nil
+	subshell, err := startSubshell(coprocess.BodyText, state, bodyIO, inheritedFiles(bodyIO))
+	if err != nil {
+		shellError(parentIO, 1, "coproc: %v", err)
+		bodyIO.Close()
+		for _, file := range []*os.File{inputReader, inputWriter, outputReader, outputWriter} {
+			file.Close()
+		}
+		substitutions.finish()
+		return 1
+	}
+
+	// The subshell has its own copies of these ends, and the shell keeps
+	// the other ends.
+	bodyIO.Close()
+	inputReader.Close()
+	outputWriter.Close()
+
+	pid := subshell.Process.Pid
+	wait := func() int {
+		status := exitStatusOf(subshell.Wait())
+		substitutions.finish()
+		return status
+	}
+
+	// The shell's ends are kept on descriptors of their own, which
+	// redirections such as ">&${NAME[1]}" refer to, until the job is gone.
+	shellEnds := []*os.File{outputReader, inputWriter}
+	var descriptors []string
+	for index, file := range shellEnds {
+		moved, err := moveDescriptor(file)
+		if err == nil {
+			shellEnds[index] = moved
+		}
+		descriptor := int(shellEnds[index].Fd())
+		shellFiles.SetFile(descriptor, shellEnds[index])
+		descriptors = append(descriptors, strconv.Itoa(descriptor))
+	}
+	variables.setArray(coprocess.Name, descriptors)
+	variables.set(coprocess.Name+"_PID", strconv.Itoa(pid))
+
+	release := func() {
+		for _, file := range shellEnds {
+			// The descriptor may have been closed or reused with exec.
+			if descriptor := int(file.Fd()); shellFiles.File(descriptor) == file {
+				shellFiles.SetFile(descriptor, nil)
+			}
+		}
+		// The variables may belong to a newer coprocess of the same name.
+		if current, ok := variables.getArray(coprocess.Name); ok && slices.Equal(current, descriptors) {
+			variables.unset(coprocess.Name)
+			variables.unset(coprocess.Name + "_PID")
+		}
+	}
+	jobs.add(coprocess.Text, pid, shellEnds, wait, release)
+	return 0
+}
@@ -0,0 +1,71 @@
+package executor
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// programName prefixes the diagnostics of commands typed at the prompt. It
+// is "shell" unless the environment variable SHELL_PROGRAM_NAME gives another
+// name when the shell starts, such as for a shell installed under that name.
+var programName = cmp.Or(os.Getenv("SHELL_PROGRAM_NAME"), "shell")
+
+// sourcePosition is where the command being run comes from: the script and
+// the line it starts on. The name is empty for commands typed at the prompt.
+var sourcePosition struct {
+	name string
+	line int
+}
+
+// diagnosticPrefix returns what a diagnostic starts with: the script and
+// line of the command being run, or else the program name.
+func diagnosticPrefix() string {
+	if sourcePosition.name != "" {
+		return fmt.Sprintf("%s: line %d: ", sourcePosition.name, sourcePosition.line)
+	}
+	return programName + ": "
+}
+
+// shellError writes a diagnostic of the shell to the error file of io and
+// returns status, the exit status the failing command ends with.
+func shellError(io shellio.IO, status int, format string, args ...any) int {
+	fmt.Fprintf(io.ErrorFile(), "%s%s\n", diagnosticPrefix(), fmt.Sprintf(format, args...))
+	return status
+}
+
+// ReportError writes a diagnostic of the shell to its error stream, for
+// errors outside of any command, such as those of the line editor.
+func ReportError(format string, args ...any) {
+	shellError(&shellFiles, 1, format, args...)
+}
+
+// usageError reports invalid options given to a builtin, followed by how the
+// builtin is used, and returns the exit status for a usage error.
+func usageError(io shellio.IO, err error, usage string) int {
+	shellError(io, 2, "%v", err)
+	fmt.Fprintln(io.ErrorFile(), usage)
+	return 2
+}
+
+// reportLineError reports an error returned by executeLine. Syntax errors
+// are reported with their position in the script or prompt the line came
+// from, followed by the offending line with a caret under the token.
+func reportLineError(io shellio.IO, err error) {
+	var syntaxError *parser.SyntaxError
+	if !errors.As(err, &syntaxError) {
+		shellError(io, 2, "%v", err)
+		return
+	}
+
+	source, line := programName, syntaxError.Line
+	if sourcePosition.name != "" {
+		source, line = sourcePosition.name, sourcePosition.line+syntaxError.Line-1
+	}
+	fmt.Fprintf(io.ErrorFile(), "%s: line %d, column %d: %v\n", source, line, syntaxError.Column, err)
+	fmt.Fprint(io.ErrorFile(), syntaxError.Snippet())
+}
@@ -0,0 +1,343 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// searchCDPATH looks for a relative directory in each entry of $CDPATH. It
+// returns the directory to change to and whether it was found through a
+// non-empty entry, in which case cd prints the new directory.
+func searchCDPATH(dir string) (string, bool) {
+	if path.IsAbs(dir) || dir == "." || dir == ".." ||
+		strings.HasPrefix(dir, "./") || strings.HasPrefix(dir, "../") {
+		return dir, false
+	}
+
+	CDPATH, _ := variables.get("CDPATH")
+	if len(CDPATH) == 0 {
+		return dir, false
+	}
+
+	for entry := range strings.SplitSeq(CDPATH, string(os.PathListSeparator)) {
+		candidate := path.Join(entry, dir)
+		if len(entry) == 0 {
+			candidate = dir
+		}
+		if fileInfo, err := os.Stat(candidate); err == nil && fileInfo.IsDir() {
+			return candidate, len(entry) > 0
+		}
+	}
+	return dir, false
+}
+
+// changeDirectory changes the working directory and updates $PWD and $OLDPWD.
+// In logical mode, target is resolved against $PWD with ".." removing the
+// previous component, so symbolic links that were followed stay part of the
+// path. In physical mode, symbolic links are resolved.
+func changeDirectory(target string, isPhysical bool) error {
+	oldDirectory := logicalWorkingDirectory()
+
+	newDirectory := target
+	if !isPhysical {
+		if !path.IsAbs(newDirectory) {
+			newDirectory = oldDirectory + "/" + newDirectory
+		}
+		cleaned, err := cleanLogicalPath(newDirectory)
+		if err != nil {
+			return err
+		}
+		newDirectory = cleaned
+	}
+
+	if err := os.Chdir(newDirectory); err != nil {
+		return err
+	}
+
+	if isPhysical {
+		physicalDirectory, err := syscall.Getwd()
+		if err != nil {
+			return err
+		}
+		newDirectory = physicalDirectory
+	}
+
+	variables.export("OLDPWD", oldDirectory)
+	variables.export("PWD", newDirectory)
+	return nil
+}
+
+// cleanLogicalPath removes "." components from an absolute path and lets
+// ".." remove the component before it. Like POSIX cd, it fails if a component
+// preceding ".." is not a directory.
+func cleanLogicalPath(dir string) (string, error) {
+	var components []string
+	for component := range strings.SplitSeq(dir, "/") {
+		switch component {
+		case "", ".":
+			continue
+		case "..":
+			prefix := "/" + strings.Join(components, "/")
+			if fileInfo, err := os.Stat(prefix); err != nil {
+				return "", err
+			} else if !fileInfo.IsDir() {
+				return "", &os.PathError{Op: "chdir", Path: prefix, Err: syscall.ENOTDIR}
+			}
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+		default:
+			components = append(components, component)
+		}
+	}
+	return "/" + strings.Join(components, "/"), nil
+}
+
+// logicalWorkingDirectory returns $PWD if it still names the current
+// directory, falling back to the physical working directory otherwise.
+func logicalWorkingDirectory() string {
+	if PWD, ok := variables.get("PWD"); ok && isCurrentDirectory(PWD) {
+		return PWD
+	}
+	if dir, err := syscall.Getwd(); err == nil {
+		return dir
+	}
+	return "."
+}
+
+func isCurrentDirectory(dir string) bool {
+	if !path.IsAbs(dir) {
+		return false
+	}
+	for component := range strings.SplitSeq(dir, "/") {
+		if component == "." || component == ".." {
+			return false
+		}
+	}
+
+	dirInfo, err := os.Stat(dir)
+	if err != nil {
+		return false
+	}
+	currentInfo, err := os.Stat(".")
+	if err != nil {
+		return false
+	}
+	return os.SameFile(dirInfo, currentInfo)
+}
+
+// DirectoryStack holds the directories saved by pushd. The current directory
+// is always the top of the stack and is not stored in entries, so cd keeps
+// the stack in sync without any extra bookkeeping.
+type DirectoryStack struct {
+	entries []string
+}
+
+// all returns the whole stack, starting with the current directory.
+func (s *DirectoryStack) all() []string {
+	return append([]string{logicalWorkingDirectory()}, s.entries...)
+}
+
+// index converts a +N or -N argument into an index into all(). +N counts
+// from the top of the stack and -N from the bottom.
+func (s *DirectoryStack) index(arg string) (int, bool) {
+	if len(arg) < 2 || (arg[0] != '+' && arg[0] != '-') {
+		return 0, false
+	}
+	n, err := strconv.Atoi(arg[1:])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	size := len(s.entries) + 1
+	if arg[0] == '-' {
+		n = size - 1 - n
+	}
+	if n < 0 || n >= size {
+		return -1, true
+	}
+	return n, true
+}
+
+func pushdCommand(args []string, io shellio.IO) int {
+	shouldChangeDirectory := true
+	if len(args) > 0 && args[0] == "-n" {
+		shouldChangeDirectory = false
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return shellError(io, 1, "pushd: too many arguments")
+	}
+
+	stack := directoryStack.all()
+	if len(args) == 0 {
+		if len(directoryStack.entries) == 0 {
+			return shellError(io, 1, "pushd: no other directory")
+		}
+		stack[0], stack[1] = stack[1], stack[0]
+		return setDirectoryStack(stack, shouldChangeDirectory, "pushd", io)
+	}
+
+	if index, isIndex := directoryStack.index(args[0]); isIndex {
+		if len(directoryStack.entries) == 0 {
+			return shellError(io, 1, "pushd: directory stack empty")
+		}
+		if index < 0 {
+			return shellError(io, 1, "pushd: %s: directory stack index out of range", args[0])
+		}
+		rotated := append(stack[index:], stack[:index]...)
+		return setDirectoryStack(rotated, shouldChangeDirectory, "pushd", io)
+	}
+
+	newDir := args[0]
+	if !shouldChangeDirectory {
+		directoryStack.entries = append([]string{newDir}, directoryStack.entries...)
+		printDirectoryStack(io, false)
+		return 0
+	}
+
+	target, _ := searchCDPATH(newDir)
+	if err := changeDirectory(target, false); err != nil {
+		return shellError(io, 1, "pushd: %s: %s", newDir, describeFileError(err))
+	}
+	directoryStack.entries = append([]string{stack[0]}, directoryStack.entries...)
+	printDirectoryStack(io, false)
+	return 0
+}
+
+func popdCommand(args []string, io shellio.IO) int {
+	shouldChangeDirectory := true
+	if len(args) > 0 && args[0] == "-n" {
+		shouldChangeDirectory = false
+		args = args[1:]
+	}
+	if len(args) > 1 {
+		return shellError(io, 1, "popd: too many arguments")
+	}
+	if len(directoryStack.entries) == 0 {
+		return shellError(io, 1, "popd: directory stack empty")
+	}
+
+	index := 0
+	if len(args) == 1 {
+		var isIndex bool
+		index, isIndex = directoryStack.index(args[0])
+		if !isIndex {
+			return usageError(io, fmt.Errorf("popd: %s: invalid argument", args[0]), "popd: usage: popd [-n] [+N | -N]")
+		}
+		if index < 0 {
+			return shellError(io, 1, "popd: %s: directory stack index out of range", args[0])
+		}
+	}
+
+	stack := directoryStack.all()
+	if index == 0 && !shouldChangeDirectory {
+		// With -n the current directory stays, so the entry below it goes.
+		index = 1
+	}
+	remaining := append(stack[:index:index], stack[index+1:]...)
+	return setDirectoryStack(remaining, shouldChangeDirectory, "popd", io)
+}
+
+// setDirectoryStack changes to the first directory of stack, unless asked
+// not to, and stores the rest as the saved entries.
+func setDirectoryStack(stack []string, shouldChangeDirectory bool, commandName string, io shellio.IO) int {
+	if shouldChangeDirectory && stack[0] != logicalWorkingDirectory() {
+		if err := changeDirectory(stack[0], false); err != nil {
+			return shellError(io, 1, "%s: %s: %s", commandName, stack[0], describeFileError(err))
+		}
+	}
+	directoryStack.entries = stack[1:]
+	printDirectoryStack(io, false)
+	return 0
+}
+
+func dirsCommand(args []string, io shellio.IO) int {
+	isLongFormat := false
+	isVerbose := false
+	isOnePerLine := false
+	isClearing := false
+	entryArg := ""
+
+	for _, arg := range args {
+		if _, isIndex := directoryStack.index(arg); isIndex {
+			entryArg = arg
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			return usageError(io, fmt.Errorf("dirs: %s: invalid argument", arg), "dirs: usage: dirs [-clpv] [+N] [-N]")
+		}
+		for _, flag := range arg[1:] {
+			switch flag {
+			case 'c':
+				directoryStack.entries = nil
+				isClearing = true
+			case 'l':
+				isLongFormat = true
+			case 'v':
+				isVerbose = true
+			case 'p':
+				isOnePerLine = true
+			default:
+				return usageError(io, fmt.Errorf("dirs: -%c: invalid option", flag), "dirs: usage: dirs [-clpv] [+N] [-N]")
+			}
+		}
+	}
+
+	// Like bash, clearing the stack lists nothing unless asked to.
+	if isClearing && !isLongFormat && !isVerbose && !isOnePerLine && entryArg == "" {
+		return 0
+	}
+
+	if entryArg != "" {
+		index, _ := directoryStack.index(entryArg)
+		if index < 0 {
+			return shellError(io, 1, "dirs: %s: directory stack index out of range", entryArg)
+		}
+		fmt.Fprintln(io.OutputFile(), formatStackEntry(directoryStack.all()[index], isLongFormat))
+		return 0
+	}
+
+	switch {
+	case isVerbose:
+		for index, dir := range directoryStack.all() {
+			fmt.Fprintf(io.OutputFile(), "%2d  %s\n", index, formatStackEntry(dir, isLongFormat))
+		}
+	case isOnePerLine:
+		for _, dir := range directoryStack.all() {
+			fmt.Fprintln(io.OutputFile(), formatStackEntry(dir, isLongFormat))
+		}
+	default:
+		printDirectoryStack(io, isLongFormat)
+	}
+	return 0
+}
+
+func printDirectoryStack(io shellio.IO, isLongFormat bool) {
+	stack := directoryStack.all()
+	for index, dir := range stack {
+		stack[index] = formatStackEntry(dir, isLongFormat)
+	}
+	fmt.Fprintln(io.OutputFile(), strings.Join(stack, " "))
+}
+
+// formatStackEntry abbreviates the home directory to ~ unless the long
+// format was asked for.
+func formatStackEntry(dir string, isLongFormat bool) string {
+	HOME, _ := variables.get("HOME")
+	if isLongFormat || len(HOME) == 0 || HOME == "/" {
+		return dir
+	}
+	if dir == HOME {
+		return "~"
+	}
+	if strings.HasPrefix(dir, HOME+"/") {
+		return "~" + dir[len(HOME):]
+	}
+	return dir
+}
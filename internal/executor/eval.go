@@ -0,0 +1,23 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// evalCommand joins its arguments with spaces and runs the result as a line
+// of input in the current shell, so it can change variables and descriptors
+// like any other command typed at the prompt. Descriptors changed by exec
+// are seen by the commands that follow it.
+func evalCommand(args []string, io shellio.IO) int {
+	input := strings.Join(args, " ")
+	if len(strings.TrimSpace(input)) == 0 {
+		return 0
+	}
+	if err := executeLine(input, followShellFiles(io)); err != nil {
+		reportLineError(io, err)
+		return 2
+	}
+	return lastExitStatus
+}
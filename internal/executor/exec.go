@@ -0,0 +1,94 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+	"golang.org/x/sys/unix"
+)
+
+// execCommand replaces the shell with a command, which runs with the files
+// of io. Without a command, the redirections given to exec apply to the
+// shell itself, so every later command sees them: "exec 3>log" keeps
+// descriptor 3 open and "exec 3>&-" closes it again.
+func execCommand(args []string, io shellio.IO) int {
+	if len(args) == 0 {
+		for _, descriptor := range io.Redirected() {
+			file := io.File(descriptor)
+			if file == nil {
+				shellFiles.SetFile(descriptor, nil)
+				continue
+			}
+			// The redirection's file is closed once exec returns, so the
+			// shell keeps a copy of its own.
+			descriptorCopy, err := unix.FcntlInt(file.Fd(), unix.F_DUPFD_CLOEXEC, 0)
+			if err != nil {
+				return shellError(io, 1, "exec: %d: %s", descriptor, describeFileError(err))
+			}
+			shellFiles.SetFile(descriptor, os.NewFile(uintptr(descriptorCopy), file.Name()))
+		}
+		return 0
+	}
+
+	commandPath, status, err := findCommand(args[0])
+	if err != nil {
+		return shellError(io, status, "exec: %s: %v", args[0], err)
+	}
+
+	if isInteractive {
+		writeHistoryToHISTFILE()
+	}
+	if err := placeDescriptors(io); err != nil {
+		return shellError(io, 1, "exec: %s", describeFileError(err))
+	}
+	err = syscall.Exec(commandPath, args, os.Environ())
+	if errors.Is(err, syscall.ENOEXEC) {
+		// A file the system cannot run is run by a new copy of the shell.
+		var executable string
+		if executable, err = os.Executable(); err == nil {
+			err = syscall.Exec(executable, shellScriptArgs(commandPath, args), os.Environ())
+		}
+	}
+
+	// The descriptors of the shell have been replaced by now, so it cannot
+	// carry on.
+	os.Exit(shellError(io, 126, "exec: %s: %s", args[0], describeFileError(err)))
+	return 126
+}
+
+// placeDescriptors makes the descriptors of the shell process refer to the
+// files of io, where a command replacing the shell expects to find them.
+// Every other descriptor is closed when the command starts.
+func placeDescriptors(io shellio.IO) error {
+	files := append([]*os.File{io.File(0), io.File(1), io.File(2)}, inheritedFiles(io)...)
+
+	// Every file is copied out of the way first, since moving one into place
+	// could otherwise overwrite the descriptor another is still on.
+	copies := make([]int, len(files))
+	for descriptor, file := range files {
+		copies[descriptor] = -1
+		if file == nil {
+			continue
+		}
+		descriptorCopy, err := unix.FcntlInt(file.Fd(), unix.F_DUPFD_CLOEXEC, len(files))
+		if err != nil {
+			return err
+		}
+		copies[descriptor] = descriptorCopy
+	}
+
+	for descriptor, descriptorCopy := range copies {
+		if descriptorCopy < 0 {
+			if descriptor < 3 {
+				unix.Close(descriptor)
+			}
+			continue
+		}
+		if err := unix.Dup2(descriptorCopy, descriptor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
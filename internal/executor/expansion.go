@@ -0,0 +1,183 @@
+package executor
+
+import (
+	"fmt"
+	"os/user"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// expandWords performs the shell expansions on the words of a command and
+// returns the resulting arguments. Brace expansion comes first and may turn
+// a word into several. Tilde expansion, parameter expansion, command
+// substitution and process substitution are then applied to each of them,
+// the unquoted results are split into fields and each field undergoes
+// pathname expansion. A pattern
+// that matches nothing is an error when failglob is set.
+func expandWords(words []parser.Word, substitutions *processSubstitutions) ([]string, error) {
+	fields := make([]string, 0, len(words))
+	for _, word := range words {
+		for _, braceExpanded := range expandBraces(word) {
+			substituted, err := expandSubstitutions(expandTilde(braceExpanded), substitutions)
+			if err != nil {
+				return nil, err
+			}
+
+			for _, field := range splitFields(substituted) {
+				matches, isPattern := expandPathname(field)
+				switch {
+				case !isPattern:
+					fields = append(fields, field.String())
+				case len(matches) > 0:
+					fields = append(fields, matches...)
+				case shellOptions.isEnabled("failglob"):
+					return nil, fmt.Errorf("no match: %s", field.String())
+				case !shellOptions.isEnabled("nullglob"):
+					fields = append(fields, field.String())
+				}
+			}
+		}
+	}
+	return fields, nil
+}
+
+// expandWord expands a word that always stays a single field, such as a
+// redirection target or an assignment.
+func expandWord(word parser.Word, substitutions *processSubstitutions) (string, error) {
+	substituted, err := expandSubstitutions(expandTilde(word), substitutions)
+	if err != nil {
+		return "", err
+	}
+	return substituted.String(), nil
+}
+
+// expandRedirections expands the targets of redirections into the file names
+// they refer to.
+func expandRedirections(redirections []parser.Redirection, substitutions *processSubstitutions) ([]shellio.RedirectionConfig, error) {
+	configs := make([]shellio.RedirectionConfig, 0, len(redirections))
+	for _, redirection := range redirections {
+		target, err := expandWord(redirection.Target, substitutions)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, shellio.NewRedirectionConfig(redirection.Operator, target))
+	}
+	return configs, nil
+}
+
+// expandTilde replaces the unquoted tilde-prefix at the start of a word. In
+// words of the form NAME=value, tilde-prefixes after the '=' and after each
+// ':' of the value are replaced too. The replacement is treated as quoted so
+// no further expansion applies to it.
+func expandTilde(word parser.Word) parser.Word {
+	if len(word) == 0 || word[0].Quoting != parser.Unquoted || word[0].Kind != parser.Literal {
+		return word
+	}
+
+	isAssignment := false
+	if name, _, found := strings.Cut(word[0].Text, "="); found && isValidVariableName(name) {
+		isAssignment = true
+	}
+
+	var expanded parser.Word
+	isAtStart := true
+	for index, part := range word {
+		if part.Quoting != parser.Unquoted || part.Kind != parser.Literal {
+			expanded = append(expanded, part)
+			isAtStart = false
+			continue
+		}
+		isLastPart := index == len(word)-1
+		expanded = append(expanded, expandTildeInPart(part.Text, isAtStart, isAssignment, isLastPart)...)
+		isAtStart = false
+	}
+	return expanded
+}
+
+// expandTildeInPart expands the tilde-prefixes of an unquoted part of a word.
+// A prefix that runs into the following quoted part is left alone, since a
+// tilde-prefix may not contain quoted characters.
+func expandTildeInPart(text string, isAtStart bool, isAssignment bool, isLastPart bool) parser.Word {
+	var parts parser.Word
+	literalStart := 0
+	isInValue := false
+
+	for index := 0; index < len(text); index++ {
+		isCandidate := index == 0 && isAtStart
+		if isAssignment && index > 0 {
+			if !isInValue && text[index-1] == '=' {
+				isInValue = true
+				isCandidate = true
+			} else if isInValue && text[index-1] == ':' {
+				isCandidate = true
+			}
+		}
+		if !isCandidate || text[index] != '~' {
+			continue
+		}
+
+		end := index + 1
+		for end < len(text) && text[end] != '/' && !(isInValue && text[end] == ':') {
+			end++
+		}
+		if end == len(text) && !isLastPart {
+			continue
+		}
+
+		value, ok := lookupTildePrefix(text[index+1 : end])
+		if !ok {
+			continue
+		}
+		if literalStart < index {
+			parts = append(parts, parser.WordPart{Text: text[literalStart:index], Quoting: parser.Unquoted})
+		}
+		parts = append(parts, parser.WordPart{Text: value, Quoting: parser.SingleQuoted})
+		literalStart = end
+		index = end - 1
+	}
+
+	if literalStart < len(text) {
+		parts = append(parts, parser.WordPart{Text: text[literalStart:], Quoting: parser.Unquoted})
+	}
+	return parts
+}
+
+// lookupTildePrefix returns the directory named by the text following a
+// tilde: the home directory for an empty prefix or a login name, $PWD for
+// "+", $OLDPWD for "-" and directory stack entries for N, +N and -N.
+func lookupTildePrefix(prefix string) (string, bool) {
+	switch prefix {
+	case "":
+		if HOME, ok := variables.get("HOME"); ok {
+			return HOME, true
+		}
+		currentUser, err := user.Current()
+		if err != nil {
+			return "", false
+		}
+		return currentUser.HomeDir, true
+	case "+":
+		return variables.get("PWD")
+	case "-":
+		return variables.get("OLDPWD")
+	}
+
+	stackIndex := prefix
+	if prefix[0] != '+' && prefix[0] != '-' {
+		stackIndex = "+" + prefix
+	}
+	if index, isIndex := directoryStack.index(stackIndex); isIndex {
+		if index < 0 {
+			return "", false
+		}
+		return directoryStack.all()[index], true
+	}
+
+	account, err := user.Lookup(prefix)
+	if err != nil {
+		return "", false
+	}
+	return account.HomeDir, true
+}
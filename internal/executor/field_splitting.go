@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+// fieldSplitter splits the results of unquoted expansions into fields using
+// the characters of $IFS.
+type fieldSplitter struct {
+	ifs    string
+	fields []parser.Word
+	// current is the field being built and hasCurrent reports whether it
+	// exists yet, since a field made only of empty quotes is still a field.
+	current    parser.Word
+	hasCurrent bool
+	// isAfterWhitespace is set when a field was just ended by IFS whitespace,
+	// which a following non-whitespace IFS character belongs to.
+	isAfterWhitespace bool
+}
+
+// splitFields splits a word whose expansions have been substituted into
+// fields. Only text that came from unquoted expansions is split; literal and
+// quoted text never is. IFS whitespace at the start and end is ignored, runs
+// of it separate fields, and every other IFS character ends a field along
+// with the whitespace around it, so "a::b" with IFS=: gives "a", "" and "b".
+// A word that expands to nothing at all produces no fields.
+func splitFields(word parser.Word) []parser.Word {
+	ifs, isSet := variables.get("IFS")
+	if !isSet {
+		ifs = defaultIFS
+	}
+	splitter := fieldSplitter{ifs: ifs}
+
+	for _, part := range word {
+		isSplittable := part.Kind != parser.Literal && part.Quoting == parser.Unquoted
+		if !isSplittable || len(ifs) == 0 {
+			if part.Quoting != parser.Unquoted || len(part.Text) > 0 {
+				splitter.appendText(part.Text, part.Quoting)
+			}
+			continue
+		}
+
+		// IFS may hold multibyte characters, so the text is split between
+		// whole characters. Invalid bytes never separate fields.
+		for index := 0; index < len(part.Text); {
+			character, size := utf8.DecodeRuneInString(part.Text[index:])
+			isInvalid := character == utf8.RuneError && size == 1
+			text := part.Text[index : index+size]
+			index += size
+
+			switch {
+			case isInvalid || !strings.ContainsRune(ifs, character):
+				splitter.appendText(text, parser.Unquoted)
+			case strings.ContainsRune(defaultIFS, character):
+				if splitter.hasCurrent {
+					splitter.endField()
+					splitter.isAfterWhitespace = true
+				}
+			case splitter.isAfterWhitespace:
+				splitter.isAfterWhitespace = false
+			default:
+				splitter.endField()
+			}
+		}
+	}
+
+	if splitter.hasCurrent {
+		splitter.endField()
+	}
+	return splitter.fields
+}
+
+// appendText adds text to the current field. Unlike the parts of the word,
+// the parts of a field are all literal; unquoted ones are still subject to
+// pathname expansion.
+func (s *fieldSplitter) appendText(text string, quoting parser.Quoting) {
+	if last := len(s.current) - 1; last >= 0 && s.current[last].Quoting == quoting {
+		s.current[last].Text += text
+	} else {
+		s.current = append(s.current, parser.WordPart{Text: text, Quoting: quoting})
+	}
+	s.hasCurrent = true
+	s.isAfterWhitespace = false
+}
+
+func (s *fieldSplitter) endField() {
+	s.fields = append(s.fields, s.current)
+	s.current = nil
+	s.hasCurrent = false
+}
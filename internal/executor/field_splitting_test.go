@@ -0,0 +1,99 @@
+package executor
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+// withIFS sets $IFS for the rest of a test, or leaves it unset if ifs is nil.
+func withIFS(t *testing.T, ifs *string) {
+	t.Helper()
+	saved := variables.values
+	variables.values = make(map[string]string)
+	if ifs != nil {
+		variables.set("IFS", *ifs)
+	}
+	t.Cleanup(func() { variables.values = saved })
+}
+
+// expandedWord returns a word made of the result of an unquoted expansion
+// between two pieces of literal text.
+func expandedWord(before, value, after string) parser.Word {
+	return parser.Word{
+		{Text: before, Quoting: parser.Unquoted},
+		{Text: value, Quoting: parser.Unquoted, Kind: parser.Parameter},
+		{Text: after, Quoting: parser.Unquoted},
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	colon, empty := ":", ""
+	tests := []struct {
+		name string
+		ifs  *string
+		word parser.Word
+		want []string
+	}{
+		{"default IFS", nil, expandedWord("", "  a b\t\nc  ", ""), []string{"a", "b", "c"}},
+		{"joined to literal text", nil, expandedWord("x", "a b", "y"), []string{"xa", "by"}},
+		{"leading blank ends literal text", nil, expandedWord("x", " a", ""), []string{"x", "a"}},
+		{"nothing at all", nil, expandedWord("", "", ""), nil},
+		{"only blanks", nil, expandedWord("", "   ", ""), nil},
+		{"non-whitespace IFS", &colon, expandedWord("", "a::b", ""), []string{"a", "", "b"}},
+		{"leading separator", &colon, expandedWord("", ":a", ""), []string{"", "a"}},
+		{"trailing separator", &colon, expandedWord("", "a:", ""), []string{"a"}},
+		{"empty IFS", &empty, expandedWord("", "a b", ""), []string{"a b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withIFS(t, test.ifs)
+			got := wordStrings(splitFields(test.word))
+			if !slices.Equal(got, test.want) {
+				t.Errorf("splitFields = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSplitFieldsLeavesLiteralAndQuotedTextAlone(t *testing.T) {
+	withIFS(t, nil)
+	tests := []struct {
+		name string
+		word parser.Word
+		want []string
+	}{
+		{
+			name: "literal blanks",
+			word: parser.Word{{Text: "a b", Quoting: parser.Unquoted}},
+			want: []string{"a b"},
+		},
+		{
+			name: "quoted expansion",
+			word: parser.Word{{Text: "a b", Quoting: parser.DoubleQuoted, Kind: parser.Parameter}},
+			want: []string{"a b"},
+		},
+		{
+			name: "empty quotes",
+			word: parser.Word{{Quoting: parser.DoubleQuoted}},
+			want: []string{""},
+		},
+		{
+			name: "quoted text beside an expansion",
+			word: parser.Word{
+				{Text: "a b", Quoting: parser.Unquoted, Kind: parser.CommandSubstitution},
+				{Text: " c", Quoting: parser.DoubleQuoted},
+			},
+			want: []string{"a", "b c"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := wordStrings(splitFields(test.word))
+			if !slices.Equal(got, test.want) {
+				t.Errorf("splitFields = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
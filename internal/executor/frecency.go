@@ -0,0 +1,286 @@
+package executor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+const (
+	frecencyFileName = "z"
+	// Once the ranks add up to more than frecencyMaxTotalRank, every rank is
+	// aged so that directories that are no longer visited are forgotten.
+	frecencyMaxTotalRank = 9000
+	frecencyAgingFactor  = 0.99
+)
+
+// frecencyEntry is a directory in the frecency database. Its rank grows with
+// every visit and its score also depends on how recently it was visited.
+type frecencyEntry struct {
+	Path       string
+	Rank       float64
+	LastAccess int64
+}
+
+func (e frecencyEntry) score(now int64) float64 {
+	age := now - e.LastAccess
+	switch {
+	case age < 3600:
+		return e.Rank * 4
+	case age < 86400:
+		return e.Rank * 2
+	case age < 604800:
+		return e.Rank / 2
+	default:
+		return e.Rank / 4
+	}
+}
+
+// frecencyFilePath returns the location of the database in the user's state
+// directory, following the XDG base directory specification.
+func frecencyFilePath() (string, bool) {
+	if stateHome, _ := variables.get("XDG_STATE_HOME"); path.IsAbs(stateHome) {
+		return path.Join(stateHome, "shell", frecencyFileName), true
+	}
+	HOME, _ := variables.get("HOME")
+	if len(HOME) == 0 {
+		return "", false
+	}
+	return path.Join(HOME, ".local", "state", "shell", frecencyFileName), true
+}
+
+func loadFrecencyEntries() []frecencyEntry {
+	fileName, ok := frecencyFilePath()
+	if !ok {
+		return nil
+	}
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var entries []frecencyEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Each line has the form "path|rank|last access".
+		fields := strings.Split(scanner.Text(), "|")
+		if len(fields) < 3 {
+			continue
+		}
+		count := len(fields)
+		rank, rankErr := strconv.ParseFloat(fields[count-2], 64)
+		lastAccess, timeErr := strconv.ParseInt(fields[count-1], 10, 64)
+		if rankErr != nil || timeErr != nil {
+			continue
+		}
+		entries = append(entries, frecencyEntry{
+			Path:       strings.Join(fields[:count-2], "|"),
+			Rank:       rank,
+			LastAccess: lastAccess,
+		})
+	}
+	return entries
+}
+
+// saveFrecencyEntries writes the database to a temporary file first, so that
+// a shell exiting midway never leaves a truncated database behind.
+func saveFrecencyEntries(entries []frecencyEntry) {
+	fileName, ok := frecencyFilePath()
+	if !ok {
+		return
+	}
+	if err := os.MkdirAll(path.Dir(fileName), 0700); err != nil {
+		return
+	}
+
+	var builder strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&builder, "%s|%s|%d\n", entry.Path, strconv.FormatFloat(entry.Rank, 'f', -1, 64), entry.LastAccess)
+	}
+
+	temporaryFileName := fmt.Sprintf("%s.%d", fileName, os.Getpid())
+	if err := os.WriteFile(temporaryFileName, []byte(builder.String()), 0600); err != nil {
+		return
+	}
+	if err := os.Rename(temporaryFileName, fileName); err != nil {
+		os.Remove(temporaryFileName)
+	}
+}
+
+// recordDirectoryVisit increases the rank of dir, adding it to the database if
+// needed. The home directory is not recorded since it is one cd away.
+func recordDirectoryVisit(dir string) {
+	if HOME, _ := variables.get("HOME"); dir == HOME || dir == "/" {
+		return
+	}
+
+	entries := loadFrecencyEntries()
+	now := time.Now().Unix()
+
+	index := slices.IndexFunc(entries, func(entry frecencyEntry) bool {
+		return entry.Path == dir
+	})
+	if index >= 0 {
+		entries[index].Rank++
+		entries[index].LastAccess = now
+	} else {
+		entries = append(entries, frecencyEntry{Path: dir, Rank: 1, LastAccess: now})
+	}
+
+	totalRank := 0.0
+	for _, entry := range entries {
+		totalRank += entry.Rank
+	}
+	if totalRank > frecencyMaxTotalRank {
+		aged := entries[:0]
+		for _, entry := range entries {
+			entry.Rank *= frecencyAgingFactor
+			if entry.Rank >= 1 {
+				aged = append(aged, entry)
+			}
+		}
+		entries = aged
+	}
+
+	saveFrecencyEntries(entries)
+}
+
+// matchFrecencyEntries returns the existing directories whose path contains
+// every keyword in order, best score first. Matching is case-sensitive unless
+// that finds nothing.
+func matchFrecencyEntries(keywords []string) []frecencyEntry {
+	entries := loadFrecencyEntries()
+	matches := filterFrecencyEntries(entries, keywords, false)
+	if len(matches) == 0 {
+		matches = filterFrecencyEntries(entries, keywords, true)
+	}
+
+	now := time.Now().Unix()
+	slices.SortStableFunc(matches, func(left, right frecencyEntry) int {
+		leftScore, rightScore := left.score(now), right.score(now)
+		if leftScore > rightScore {
+			return -1
+		} else if leftScore < rightScore {
+			return 1
+		}
+		return 0
+	})
+	return matches
+}
+
+func filterFrecencyEntries(entries []frecencyEntry, keywords []string, ignoreCase bool) []frecencyEntry {
+	var matches []frecencyEntry
+	for _, entry := range entries {
+		if !containsInOrder(entry.Path, keywords, ignoreCase) {
+			continue
+		}
+		if fileInfo, err := os.Stat(entry.Path); err != nil || !fileInfo.IsDir() {
+			continue
+		}
+		matches = append(matches, entry)
+	}
+	return matches
+}
+
+func containsInOrder(text string, keywords []string, ignoreCase bool) bool {
+	if ignoreCase {
+		text = strings.ToLower(text)
+	}
+	for _, keyword := range keywords {
+		if ignoreCase {
+			keyword = strings.ToLower(keyword)
+		}
+		index := strings.Index(text, keyword)
+		if index < 0 {
+			return false
+		}
+		text = text[index+len(keyword):]
+	}
+	return true
+}
+
+// FrecencyMatches returns the directories matching keywords, best first. It
+// is used to complete the arguments of z.
+func FrecencyMatches(keywords []string) []string {
+	var paths []string
+	for _, entry := range matchFrecencyEntries(keywords) {
+		paths = append(paths, entry.Path)
+	}
+	return paths
+}
+
+func zCommand(args []string, io shellio.IO) int {
+	options, keywords, err := parseBuiltinOptions("z", args, "lx")
+	if err != nil {
+		return usageError(io, err, "z: usage: z [-l | -x] [keyword ...]")
+	}
+
+	isListing := len(keywords) == 0
+	isRemoving := false
+	for _, option := range options {
+		switch option.flag {
+		case 'l':
+			isListing = true
+		case 'x':
+			isRemoving = true
+		}
+	}
+
+	if isRemoving {
+		return removeFrecencyEntries(keywords, io)
+	}
+
+	matches := matchFrecencyEntries(keywords)
+	if isListing {
+		// The best match is listed last, right above the next prompt.
+		now := time.Now().Unix()
+		for index := len(matches) - 1; index >= 0; index-- {
+			fmt.Fprintf(io.OutputFile(), "%-10s %s\n", strconv.FormatFloat(matches[index].score(now), 'g', 6, 64), matches[index].Path)
+		}
+		return 0
+	}
+
+	if len(matches) == 0 {
+		return shellError(io, 1, "z: %s: no matching directory", strings.Join(keywords, " "))
+	}
+	if err := changeDirectory(matches[0].Path, false); err != nil {
+		return shellError(io, 1, "z: %s: %s", matches[0].Path, describeFileError(err))
+	}
+	recordDirectoryVisit(logicalWorkingDirectory())
+	return 0
+}
+
+// removeFrecencyEntries forgets the given directories, or the current
+// directory if none are given.
+func removeFrecencyEntries(dirs []string, io shellio.IO) int {
+	if len(dirs) == 0 {
+		dirs = []string{logicalWorkingDirectory()}
+	}
+
+	entries := loadFrecencyEntries()
+	status := 0
+	for _, dir := range dirs {
+		if !path.IsAbs(dir) {
+			dir = path.Join(logicalWorkingDirectory(), dir)
+		}
+		index := slices.IndexFunc(entries, func(entry frecencyEntry) bool {
+			return entry.Path == dir
+		})
+		if index < 0 {
+			status = shellError(io, 1, "z: %s: not in database", dir)
+			continue
+		}
+		entries = slices.Delete(entries, index, index+1)
+	}
+
+	saveFrecencyEntries(entries)
+	return status
+}
@@ -0,0 +1,540 @@
+package executor
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+type globNodeKind int
+
+const (
+	globLiteral   globNodeKind = iota // A character that matches itself
+	globAnyChar                       // ?
+	globAnyString                     // *
+	globBracket                       // [...]
+	globExtended                      // ?(...), *(...), +(...), @(...) or !(...)
+)
+
+// globNode is a single element of a compiled pattern.
+type globNode struct {
+	kind         globNodeKind
+	character    rune
+	bracket      *bracketExpression
+	operator     byte
+	alternatives []globPattern
+}
+
+type globPattern []globNode
+
+// bracketExpression is a [...] expression matching a single character.
+type bracketExpression struct {
+	isNegated  bool
+	characters []rune
+	ranges     [][2]rune
+	classes    []string
+}
+
+// decodeGlobCharacter returns the first character of text and its length in
+// bytes. Patterns and file names are matched a character at a time, so that
+// '?' matches a whole multibyte character. Bytes that are not valid UTF-8
+// are characters of their own, each distinct from every valid character.
+func decodeGlobCharacter(text string) (rune, int) {
+	character, size := utf8.DecodeRuneInString(text)
+	if character == utf8.RuneError && size == 1 {
+		return utf8.MaxRune + 1 + rune(text[0]), 1
+	}
+	return character, size
+}
+
+// decodeUnitCharacter decodes the character starting at units[index], which
+// may span several single-byte units.
+func decodeUnitCharacter(units []parser.WordPart, index int) (rune, int) {
+	var builder strings.Builder
+	for offset := index; offset < len(units) && offset < index+utf8.UTFMax; offset++ {
+		builder.WriteString(units[offset].Text)
+	}
+	return decodeGlobCharacter(builder.String())
+}
+
+// globMatcher matches text against patterns, optionally ignoring case.
+type globMatcher struct {
+	ignoreCase bool
+}
+
+// compileGlobPattern compiles the units of a word, as returned by
+// splitWordUnits, into a pattern. Quoted characters always match themselves.
+// It also reports whether the pattern has any unquoted special characters.
+func compileGlobPattern(units []parser.WordPart, isExtendedEnabled bool) (globPattern, bool) {
+	index := 0
+	pattern, hasSpecial, _ := parseGlobSequence(units, &index, isExtendedEnabled, false)
+	return pattern, hasSpecial
+}
+
+// parseGlobSequence parses units until the end or, inside an extended
+// pattern, until an unquoted '|' or ')'. The last result reports whether the
+// sequence was ended by one of those.
+func parseGlobSequence(units []parser.WordPart, index *int, isExtendedEnabled bool, isInsideExtended bool) (globPattern, bool, bool) {
+	var pattern globPattern
+	hasSpecial := false
+
+	for *index < len(units) {
+		unit := units[*index]
+		character := unit.Text[0]
+
+		if unit.Quoting != parser.Unquoted {
+			literal, size := decodeUnitCharacter(units, *index)
+			pattern = append(pattern, globNode{kind: globLiteral, character: literal})
+			*index += size
+			continue
+		}
+
+		if isInsideExtended && (character == '|' || character == ')') {
+			return pattern, hasSpecial, true
+		}
+
+		if isExtendedEnabled && strings.IndexByte("?*+@!", character) >= 0 &&
+			*index+1 < len(units) && isUnquotedUnit(units[*index+1], "(") {
+			if node, ok := parseExtendedGlob(units, index, isExtendedEnabled); ok {
+				pattern = append(pattern, node)
+				hasSpecial = true
+				continue
+			}
+		}
+
+		switch character {
+		case '?':
+			pattern = append(pattern, globNode{kind: globAnyChar})
+			hasSpecial = true
+		case '*':
+			// Consecutive stars match the same as a single one.
+			if len(pattern) == 0 || pattern[len(pattern)-1].kind != globAnyString {
+				pattern = append(pattern, globNode{kind: globAnyString})
+			}
+			hasSpecial = true
+		case '[':
+			if bracket, end, ok := parseBracketExpression(units, *index); ok {
+				pattern = append(pattern, globNode{kind: globBracket, bracket: bracket})
+				hasSpecial = true
+				*index = end
+			} else {
+				pattern = append(pattern, globNode{kind: globLiteral, character: '['})
+			}
+		default:
+			literal, size := decodeUnitCharacter(units, *index)
+			pattern = append(pattern, globNode{kind: globLiteral, character: literal})
+			*index += size - 1
+		}
+		*index++
+	}
+
+	return pattern, hasSpecial, false
+}
+
+// parseExtendedGlob parses an extended pattern such as +(a|b) starting at the
+// operator. If the parentheses are not closed, nothing is consumed.
+func parseExtendedGlob(units []parser.WordPart, index *int, isExtendedEnabled bool) (globNode, bool) {
+	position := *index + 2
+	node := globNode{kind: globExtended, operator: units[*index].Text[0]}
+
+	for {
+		alternative, _, isTerminated := parseGlobSequence(units, &position, isExtendedEnabled, true)
+		if !isTerminated {
+			return globNode{}, false
+		}
+		node.alternatives = append(node.alternatives, alternative)
+
+		terminator := units[position].Text[0]
+		position++
+		if terminator == ')' {
+			*index = position
+			return node, true
+		}
+	}
+}
+
+// parseBracketExpression parses the bracket expression starting at open and
+// returns it along with the index of its closing ']'.
+func parseBracketExpression(units []parser.WordPart, open int) (*bracketExpression, int, bool) {
+	bracket := &bracketExpression{}
+	index := open + 1
+	if index < len(units) && units[index].Quoting == parser.Unquoted && (units[index].Text == "!" || units[index].Text == "^") {
+		bracket.isNegated = true
+		index++
+	}
+
+	isFirst := true
+	for ; index < len(units); index++ {
+		unit := units[index]
+		character := unit.Text[0]
+		isUnquoted := unit.Quoting == parser.Unquoted
+
+		if isUnquoted && character == ']' && !isFirst {
+			return bracket, index, true
+		}
+		isFirst = false
+
+		if isUnquoted && character == '[' && index+1 < len(units) && isUnquotedUnit(units[index+1], ":") {
+			if end := findClassEnd(units, index+2); end >= 0 {
+				bracket.classes = append(bracket.classes, joinWordUnits(units[index+2:end]).String())
+				index = end + 1
+				continue
+			}
+		}
+
+		member, size := decodeUnitCharacter(units, index)
+		dash := index + size
+		if dash+1 < len(units) && isUnquotedUnit(units[dash], "-") && !isUnquotedUnit(units[dash+1], "]") {
+			end, endSize := decodeUnitCharacter(units, dash+1)
+			bracket.ranges = append(bracket.ranges, [2]rune{member, end})
+			index = dash + endSize
+			continue
+		}
+		bracket.characters = append(bracket.characters, member)
+		index += size - 1
+	}
+	return nil, 0, false
+}
+
+// findClassEnd returns the index of the ':' of the ":]" that ends a character
+// class name starting at start, or -1.
+func findClassEnd(units []parser.WordPart, start int) int {
+	for index := start; index+1 < len(units); index++ {
+		if isUnquotedUnit(units[index], ":") && isUnquotedUnit(units[index+1], "]") {
+			return index
+		}
+		if !isLetter(units[index].Text[0]) {
+			return -1
+		}
+	}
+	return -1
+}
+
+func (b *bracketExpression) matches(character rune, ignoreCase bool) bool {
+	isMatch := b.contains(character)
+	if !isMatch && ignoreCase {
+		isMatch = b.contains(unicode.ToLower(character)) || b.contains(unicode.ToUpper(character))
+	}
+	return isMatch != b.isNegated
+}
+
+func (b *bracketExpression) contains(character rune) bool {
+	if slices.Contains(b.characters, character) {
+		return true
+	}
+	for _, characterRange := range b.ranges {
+		if character >= characterRange[0] && character <= characterRange[1] {
+			return true
+		}
+	}
+	for _, class := range b.classes {
+		if isInCharacterClass(character, class) {
+			return true
+		}
+	}
+	return false
+}
+
+func isInCharacterClass(character rune, class string) bool {
+	switch class {
+	case "alpha":
+		return unicode.IsLetter(character)
+	case "digit":
+		return character >= '0' && character <= '9'
+	case "alnum":
+		return unicode.IsLetter(character) || unicode.IsDigit(character)
+	case "upper":
+		return unicode.IsUpper(character)
+	case "lower":
+		return unicode.IsLower(character)
+	case "space":
+		return unicode.IsSpace(character)
+	case "blank":
+		return character == ' ' || character == '\t'
+	case "punct":
+		return unicode.IsPunct(character) || unicode.IsSymbol(character)
+	case "xdigit":
+		return (character >= '0' && character <= '9') || (character >= 'a' && character <= 'f') || (character >= 'A' && character <= 'F')
+	case "cntrl":
+		return unicode.IsControl(character)
+	case "print":
+		return unicode.IsPrint(character)
+	case "graph":
+		return unicode.IsGraphic(character) && !unicode.IsSpace(character)
+	}
+	return false
+}
+
+func equalFoldCharacters(left rune, right rune) bool {
+	return left == right || unicode.ToLower(left) == unicode.ToLower(right)
+}
+
+// match reports whether the whole of text matches pattern.
+func (m globMatcher) match(pattern globPattern, text string) bool {
+	if len(pattern) == 0 {
+		return len(text) == 0
+	}
+
+	node, rest := pattern[0], pattern[1:]
+	switch node.kind {
+	case globLiteral:
+		if len(text) == 0 {
+			return false
+		}
+		character, size := decodeGlobCharacter(text)
+		if character != node.character && !(m.ignoreCase && equalFoldCharacters(character, node.character)) {
+			return false
+		}
+		return m.match(rest, text[size:])
+	case globAnyChar:
+		if len(text) == 0 {
+			return false
+		}
+		_, size := decodeGlobCharacter(text)
+		return m.match(rest, text[size:])
+	case globAnyString:
+		for index := 0; index <= len(text); index = nextBoundary(text, index) {
+			if m.match(rest, text[index:]) {
+				return true
+			}
+		}
+		return false
+	case globBracket:
+		if len(text) == 0 {
+			return false
+		}
+		character, size := decodeGlobCharacter(text)
+		return node.bracket.matches(character, m.ignoreCase) && m.match(rest, text[size:])
+	case globExtended:
+		return m.matchExtended(node, rest, text)
+	}
+	return false
+}
+
+// matchExtended matches an extended pattern followed by the rest of the
+// pattern, trying every way of splitting text between the two.
+func (m globMatcher) matchExtended(node globNode, rest globPattern, text string) bool {
+	switch node.operator {
+	case '@', '?':
+		if node.operator == '?' && m.match(rest, text) {
+			return true
+		}
+		for end := 0; end <= len(text); end = nextBoundary(text, end) {
+			if m.matchAnyAlternative(node, text[:end]) && m.match(rest, text[end:]) {
+				return true
+			}
+		}
+	case '*', '+':
+		if node.operator == '*' && m.match(rest, text) {
+			return true
+		}
+		// Each repetition must consume something so that the recursion ends.
+		repeated := append(globPattern{{kind: globExtended, operator: '*', alternatives: node.alternatives}}, rest...)
+		for end := nextBoundary(text, 0); end <= len(text); end = nextBoundary(text, end) {
+			if m.matchAnyAlternative(node, text[:end]) && m.match(repeated, text[end:]) {
+				return true
+			}
+		}
+		if node.operator == '+' && m.matchAnyAlternative(node, "") && m.match(rest, text) {
+			return true
+		}
+	case '!':
+		for end := 0; end <= len(text); end = nextBoundary(text, end) {
+			if !m.matchAnyAlternative(node, text[:end]) && m.match(rest, text[end:]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nextBoundary returns the position of the character following the one at
+// index, so that text is only ever split between whole characters.
+func nextBoundary(text string, index int) int {
+	if index >= len(text) {
+		return index + 1
+	}
+	_, size := decodeGlobCharacter(text[index:])
+	return index + size
+}
+
+func (m globMatcher) matchAnyAlternative(node globNode, text string) bool {
+	for _, alternative := range node.alternatives {
+		if m.match(alternative, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// globComponent is one slash-separated component of a pathname pattern.
+type globComponent struct {
+	literal     string
+	pattern     globPattern
+	isPattern   bool
+	isGlobstar  bool
+	startsOnDot bool
+}
+
+// expandPathname performs pathname expansion on a word. It returns the sorted
+// matching paths and whether the word contained a pattern at all.
+func expandPathname(word parser.Word) ([]string, bool) {
+	// Empty quotes have no bearing on the pattern.
+	units := slices.DeleteFunc(splitWordUnits(word), func(unit parser.WordPart) bool {
+		return len(unit.Text) == 0
+	})
+	isExtendedEnabled := shellOptions.isEnabled("extglob")
+
+	var components []globComponent
+	hasPattern := false
+	isAbsolute := len(units) > 0 && isUnquotedUnit(units[0], "/")
+	hasTrailingSlash := len(units) > 0 && isUnquotedUnit(units[len(units)-1], "/")
+
+	start := 0
+	for index := 0; index <= len(units); index++ {
+		if index < len(units) && !isUnquotedUnit(units[index], "/") {
+			continue
+		}
+		componentUnits := units[start:index]
+		start = index + 1
+		if len(componentUnits) == 0 {
+			continue
+		}
+
+		pattern, isPattern := compileGlobPattern(componentUnits, isExtendedEnabled)
+		literal := joinWordUnits(componentUnits).String()
+		component := globComponent{
+			literal:     literal,
+			pattern:     pattern,
+			isPattern:   isPattern,
+			startsOnDot: componentUnits[0].Text == ".",
+		}
+		if isPattern && literal == "**" && componentUnits[0].Quoting == parser.Unquoted && shellOptions.isEnabled("globstar") {
+			component.isGlobstar = true
+		}
+		hasPattern = hasPattern || isPattern
+		components = append(components, component)
+	}
+
+	if !hasPattern {
+		return nil, false
+	}
+
+	paths := []string{""}
+	if isAbsolute {
+		paths = []string{"/"}
+	}
+	matcher := globMatcher{ignoreCase: shellOptions.isEnabled("nocaseglob")}
+
+	for index, component := range components {
+		isLast := index == len(components)-1
+		var next []string
+
+		for _, base := range paths {
+			switch {
+			case component.isGlobstar:
+				next = append(next, globstarPaths(base, isLast && !hasTrailingSlash)...)
+			case component.isPattern:
+				next = append(next, matchDirectoryEntries(base, component, matcher, !isLast || hasTrailingSlash)...)
+			default:
+				candidate := joinGlobPath(base, component.literal)
+				if _, err := os.Lstat(globDirectory(candidate)); err == nil {
+					next = append(next, candidate)
+				}
+			}
+		}
+		paths = next
+	}
+
+	var matches []string
+	for _, match := range paths {
+		if match == "" {
+			continue
+		}
+		if hasTrailingSlash {
+			match += "/"
+		}
+		matches = append(matches, match)
+	}
+	slices.Sort(matches)
+	return slices.Compact(matches), true
+}
+
+// matchDirectoryEntries returns the entries of base matching a component.
+// Hidden entries only match if the component starts with a '.' or dotglob is
+// set. If onlyDirectories is set, entries that are not directories are left
+// out, since a later component has to look inside them.
+func matchDirectoryEntries(base string, component globComponent, matcher globMatcher, onlyDirectories bool) []string {
+	entries, err := os.ReadDir(globDirectory(base))
+	if err != nil {
+		return nil
+	}
+
+	var matches []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !component.startsOnDot && !shellOptions.isEnabled("dotglob") {
+			continue
+		}
+		if !matcher.match(component.pattern, name) {
+			continue
+		}
+		candidate := joinGlobPath(base, name)
+		if onlyDirectories && !isDirectory(candidate) {
+			continue
+		}
+		matches = append(matches, candidate)
+	}
+	return matches
+}
+
+// globstarPaths returns base and every directory below it for a "**"
+// component. When "**" is the last component, files are included as well.
+// Symbolic links to directories are not followed.
+func globstarPaths(base string, includeFiles bool) []string {
+	paths := []string{base}
+	entries, err := os.ReadDir(globDirectory(base))
+	if err != nil {
+		return paths
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && !shellOptions.isEnabled("dotglob") {
+			continue
+		}
+		candidate := joinGlobPath(base, name)
+		if entry.IsDir() {
+			paths = append(paths, globstarPaths(candidate, includeFiles)...)
+		} else if includeFiles {
+			paths = append(paths, candidate)
+		}
+	}
+	return paths
+}
+
+func joinGlobPath(base string, name string) string {
+	if base == "" {
+		return name
+	}
+	if strings.HasSuffix(base, "/") {
+		return base + name
+	}
+	return base + "/" + name
+}
+
+func globDirectory(base string) string {
+	if base == "" {
+		return "."
+	}
+	return base
+}
+
+func isDirectory(name string) bool {
+	fileInfo, err := os.Stat(name)
+	return err == nil && fileInfo.IsDir()
+}
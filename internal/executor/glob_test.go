@@ -0,0 +1,158 @@
+package executor
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+func matchGlob(pattern string, text string, isExtendedEnabled bool, ignoreCase bool) bool {
+	compiled, _ := compileGlobPattern(splitWordUnits(unquotedWord(pattern)), isExtendedEnabled)
+	return globMatcher{ignoreCase: ignoreCase}.match(compiled, text)
+}
+
+func TestGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "main.c", false},
+		{"*", "", true},
+		{"?", "a", true},
+		{"?", "", false},
+		{"a?c", "abc", true},
+		{"a*b*c", "axxbyyc", true},
+		{"a*b*c", "axxbyy", false},
+		{"[abc]x", "bx", true},
+		{"[a-c]x", "dx", false},
+		{"[!a-c]x", "dx", true},
+		{"[^a-c]x", "ax", false},
+		{"[[:digit:]]*", "7up", true},
+		{"[[:upper:]]", "a", false},
+		{"[]]", "]", true},
+	}
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.text, false, false); got != test.want {
+			t.Errorf("%q matching %q = %v, want %v", test.pattern, test.text, got, test.want)
+		}
+	}
+}
+
+func TestGlobMatchQuoted(t *testing.T) {
+	word := parser.Word{{Text: "*", Quoting: parser.SingleQuoted}, {Text: "*", Quoting: parser.Unquoted}}
+	pattern, hasSpecial := compileGlobPattern(splitWordUnits(word), false)
+	if !hasSpecial {
+		t.Errorf("pattern with an unquoted * has no special characters")
+	}
+	if !(globMatcher{}).match(pattern, "*x") || (globMatcher{}).match(pattern, "x*") {
+		t.Errorf("quoted * is not matched literally")
+	}
+	if _, hasSpecial := compileGlobPattern(splitWordUnits(parser.Word{{Text: "*?", Quoting: parser.DoubleQuoted}}), false); hasSpecial {
+		t.Errorf("fully quoted pattern has special characters")
+	}
+}
+
+func TestGlobMatchIgnoreCase(t *testing.T) {
+	if !matchGlob("*.GO", "main.go", false, true) {
+		t.Errorf("nocaseglob match failed")
+	}
+	if matchGlob("*.GO", "main.go", false, false) {
+		t.Errorf("case-sensitive match ignored case")
+	}
+}
+
+func TestExtendedGlobMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		text    string
+		want    bool
+	}{
+		{"@(a|b).txt", "a.txt", true},
+		{"@(a|b).txt", "c.txt", false},
+		{"?(x)y", "y", true},
+		{"?(x)y", "xy", true},
+		{"?(x)y", "xxy", false},
+		{"*(ab)", "", true},
+		{"*(ab)", "ababab", true},
+		{"*(ab)", "aba", false},
+		{"+(ab)", "", false},
+		{"+(ab)c", "ababc", true},
+		{"!(*.go)", "main.c", true},
+		{"!(*.go)", "main.go", false},
+		{"*.@(jpg|png)", "photo.png", true},
+	}
+	for _, test := range tests {
+		if got := matchGlob(test.pattern, test.text, true, false); got != test.want {
+			t.Errorf("%q matching %q = %v, want %v", test.pattern, test.text, got, test.want)
+		}
+	}
+}
+
+func TestExtendedGlobNeedsExtglob(t *testing.T) {
+	if matchGlob("@(a|b)", "a", false, false) {
+		t.Errorf("@(a|b) matched a without extglob")
+	}
+	if !matchGlob("@(a|b)", "@(a|b)", false, false) {
+		t.Errorf("@(a|b) is not literal without extglob")
+	}
+}
+
+// withShellOptions turns on the named shopt options for the rest of a test.
+func withShellOptions(t *testing.T, names ...string) {
+	t.Helper()
+	saved := shellOptions.enabled
+	shellOptions.enabled = make(map[string]bool)
+	for _, name := range names {
+		shellOptions.set(name, true)
+	}
+	t.Cleanup(func() { shellOptions.enabled = saved })
+}
+
+func TestExpandPathname(t *testing.T) {
+	directory := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "c.txt", ".hidden.go", "sub/d.go", "sub/deep/e.go"} {
+		path := filepath.Join(directory, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Chdir(directory)
+
+	tests := []struct {
+		name    string
+		options []string
+		pattern string
+		want    []string
+	}{
+		{"star", nil, "*.go", []string{"a.go", "b.go"}},
+		{"dotglob", []string{"dotglob"}, "*.go", []string{".hidden.go", "a.go", "b.go"}},
+		{"explicit dot", nil, ".*.go", []string{".hidden.go"}},
+		{"directories", nil, "*/", []string{"sub/"}},
+		{"in a directory", nil, "sub/*.go", []string{"sub/d.go"}},
+		{"** without globstar", nil, "**/*.go", []string{"sub/d.go"}},
+		{"globstar", []string{"globstar"}, "**/*.go", []string{"a.go", "b.go", "sub/d.go", "sub/deep/e.go"}},
+		{"nocaseglob", []string{"nocaseglob"}, "*.TXT", []string{"c.txt"}},
+		{"extglob", []string{"extglob"}, "!(*.go)", []string{"c.txt", "sub"}},
+		{"no match", nil, "*.rs", nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			withShellOptions(t, test.options...)
+			got, isPattern := expandPathname(unquotedWord(test.pattern))
+			if !isPattern {
+				t.Fatalf("%q is not a pattern", test.pattern)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("expandPathname(%q) = %q, want %q", test.pattern, got, test.want)
+			}
+		})
+	}
+}
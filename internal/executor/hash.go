@@ -0,0 +1,167 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// HashTable remembers where in $PATH commands were found, so that running
+// one again does not search every directory. The table is emptied whenever
+// $PATH changes.
+type HashTable struct {
+	entries map[string]*hashEntry
+	path    string // The $PATH the entries were found with
+}
+
+type hashEntry struct {
+	path string
+	hits int
+}
+
+// sync empties the table if $PATH has changed since the entries were found.
+func (t *HashTable) sync() {
+	if PATH := os.Getenv("PATH"); PATH != t.path {
+		t.entries = nil
+		t.path = PATH
+	}
+}
+
+// resolve returns the path of the command to run for name, remembering it
+// and counting the hit. A remembered file that has since gone is looked for
+// again.
+func (t *HashTable) resolve(name string) (string, bool) {
+	t.sync()
+	if entry, ok := t.entries[name]; ok && isExecutableFile(entry.path) {
+		entry.hits++
+		return entry.path, true
+	}
+	path, ok := findPath(name)
+	if !ok {
+		delete(t.entries, name)
+		return "", false
+	}
+	t.set(name, path)
+	t.entries[name].hits = 1
+	return path, true
+}
+
+// lookup returns the remembered path of name, without counting a hit.
+func (t *HashTable) lookup(name string) (string, bool) {
+	t.sync()
+	entry, ok := t.entries[name]
+	if !ok {
+		return "", false
+	}
+	return entry.path, true
+}
+
+// find returns the path name would run, from the table or else from $PATH,
+// without remembering it.
+func (t *HashTable) find(name string) (string, bool) {
+	if path, ok := t.lookup(name); ok {
+		return path, true
+	}
+	return findPath(name)
+}
+
+func (t *HashTable) set(name string, path string) {
+	t.sync()
+	if t.entries == nil {
+		t.entries = make(map[string]*hashEntry)
+	}
+	t.entries[name] = &hashEntry{path: path}
+}
+
+func (t *HashTable) remove(name string) bool {
+	t.sync()
+	if _, ok := t.entries[name]; !ok {
+		return false
+	}
+	delete(t.entries, name)
+	return true
+}
+
+func (t *HashTable) clear() {
+	t.entries = nil
+}
+
+func (t *HashTable) names() []string {
+	t.sync()
+	names := make([]string, 0, len(t.entries))
+	for name := range t.entries {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// hashCommand lists the remembered commands with the number of times each
+// has run, or looks up the given names in $PATH and remembers them. -r
+// forgets every command, -d the given ones, and -p remembers path as the
+// command to run for name.
+func hashCommand(args []string, io shellio.IO) int {
+	options, operands, err := parseBuiltinOptions("hash", args, "rdp:")
+	if err != nil {
+		return usageError(io, err, "hash: usage: hash [-r] [-p pathname] [-d] [name ...]")
+	}
+
+	isDelete, path, hasPath := false, "", false
+	for _, option := range options {
+		switch option.flag {
+		case 'r':
+			hashedCommands.clear()
+		case 'd':
+			isDelete = true
+		case 'p':
+			path, hasPath = option.value, true
+		}
+	}
+
+	if len(operands) == 0 {
+		if len(options) == 0 {
+			printHashedCommands(io)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, name := range operands {
+		switch {
+		case isDelete:
+			if !hashedCommands.remove(name) {
+				status = shellError(io, 1, "hash: %s: not found", name)
+			}
+		case hasPath:
+			hashedCommands.set(name, path)
+		default:
+			// Builtins run without searching $PATH, so they are never
+			// remembered.
+			if _, isBuiltin := builtinCommands[name]; isBuiltin {
+				continue
+			}
+			path, ok := findPath(name)
+			if !ok {
+				status = shellError(io, 1, "hash: %s: not found", name)
+				continue
+			}
+			hashedCommands.set(name, path)
+		}
+	}
+	return status
+}
+
+func printHashedCommands(io shellio.IO) {
+	names := hashedCommands.names()
+	if len(names) == 0 {
+		fmt.Fprintln(io.OutputFile(), "hash: hash table empty")
+		return
+	}
+	fmt.Fprintln(io.OutputFile(), "hits\tcommand")
+	for _, name := range names {
+		entry := hashedCommands.entries[name]
+		fmt.Fprintf(io.OutputFile(), "%4d\t%s\n", entry.hits, entry.path)
+	}
+}
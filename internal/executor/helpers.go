@@ -1,11 +1,19 @@
 package executor
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
 	"path"
 	"strconv"
 	"strings"
+	"syscall"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+	"golang.org/x/sys/unix"
 )
 
 // initializePipes creates a specified number of pipes for inter-process communication.
@@ -19,7 +27,7 @@ func initializePipes(numPipes int) ([][2]*os.File, error) {
 				pipes[j][0].Close()
 				pipes[j][1].Close()
 			}
-			return nil, fmt.Errorf("shell: error creating pipe %d: %v", i, err)
+			return nil, fmt.Errorf("error creating pipe %d: %v", i, err)
 		}
 		pipes[i] = [2]*os.File{r, w}
 	}
@@ -28,13 +36,18 @@ func initializePipes(numPipes int) ([][2]*os.File, error) {
 
 func loadHistoryFromHISTFILE() {
 	if historyFileName, isPresent := os.LookupEnv("HISTFILE"); isPresent {
-		history.appendFromFile(historyFileName)
+		// The file does not exist until the history is first saved.
+		if err := history.appendFromFile(historyFileName); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			shellError(&shellFiles, 1, "history: %s: %s", historyFileName, describeFileError(err))
+		}
 	}
 }
 
 func writeHistoryToHISTFILE() {
 	if historyFileName, isPresent := os.LookupEnv("HISTFILE"); isPresent {
-		history.saveToFile(historyFileName)
+		if err := history.saveToFile(historyFileName); err != nil {
+			shellError(&shellFiles, 1, "history: %s: %s", historyFileName, describeFileError(err))
+		}
 	}
 }
 
@@ -48,15 +61,190 @@ func parseHistoryLimit(stringLimit string) (int, error) {
 	return limit, nil
 }
 
+// moveDescriptor moves file to a descriptor of 60 or above. The descriptors
+// the shell hands out by number, such as those of coprocesses, are kept
+// there, out of the way of the small ones scripts pick for themselves.
+func moveDescriptor(file *os.File) (*os.File, error) {
+	descriptor, err := unix.FcntlInt(file.Fd(), unix.F_DUPFD_CLOEXEC, 60)
+	if err != nil {
+		return nil, err
+	}
+	moved := os.NewFile(uintptr(descriptor), file.Name())
+	file.Close()
+	return moved, nil
+}
+
+// findCommand returns the path of the program to run for a command name,
+// along with the exit status and error to report if there is none. A name
+// containing a slash is the path itself; any other is looked up in $PATH.
+func findCommand(name string) (string, int, error) {
+	if !strings.Contains(name, "/") {
+		if path, ok := hashedCommands.resolve(name); ok {
+			return path, 0, nil
+		}
+		return "", 127, errors.New("command not found")
+	}
+
+	fileInfo, err := os.Stat(name)
+	switch {
+	case err != nil:
+		return "", 127, errors.New(describeFileError(err))
+	case fileInfo.IsDir():
+		return "", 126, errors.New(describeFileError(syscall.EISDIR))
+	case unix.Access(name, unix.X_OK) != nil:
+		return "", 126, errors.New(describeFileError(syscall.EACCES))
+	}
+	return name, 0, nil
+}
+
 func findPath(command string) (string, bool) {
-	PATH := os.Getenv("PATH")
-	directories := strings.SplitSeq(PATH, string(os.PathListSeparator))
+	return findPathIn(command, os.Getenv("PATH"))
+}
 
-	for dir := range directories {
-		fullPath := path.Join(dir, command)
-		if fileInfo, err := os.Stat(fullPath); err == nil && fileInfo.Mode().IsRegular() && (fileInfo.Mode().Perm()&0111 != 0) {
+// findPathIn looks for an executable file named command in the directories
+// of PATH, a colon-separated list like $PATH. A name containing a slash is
+// never looked up.
+func findPathIn(command string, PATH string) (string, bool) {
+	if strings.Contains(command, "/") {
+		return "", false
+	}
+	for dir := range strings.SplitSeq(PATH, string(os.PathListSeparator)) {
+		if fullPath := path.Join(dir, command); isExecutableFile(fullPath) {
 			return fullPath, true
 		}
 	}
 	return "", false
 }
+
+// findAllPaths returns every executable file named command in the
+// directories of $PATH, in the order they are searched.
+func findAllPaths(command string) []string {
+	var paths []string
+	if strings.Contains(command, "/") {
+		return nil
+	}
+	for dir := range strings.SplitSeq(os.Getenv("PATH"), string(os.PathListSeparator)) {
+		if fullPath := path.Join(dir, command); isExecutableFile(fullPath) {
+			paths = append(paths, fullPath)
+		}
+	}
+	return paths
+}
+
+func isExecutableFile(name string) bool {
+	fileInfo, err := os.Stat(name)
+	return err == nil && fileInfo.Mode().IsRegular() && (fileInfo.Mode().Perm()&0111 != 0)
+}
+
+func isEchoOption(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	for _, flag := range arg[1:] {
+		if flag != 'n' && flag != 'e' && flag != 'E' {
+			return false
+		}
+	}
+	return true
+}
+
+// expandEchoEscapes decodes the backslash escapes understood by echo -e. The
+// returned flag reports whether a \c escape cut the output short.
+func expandEchoEscapes(text string) (string, bool) {
+	var builder strings.Builder
+	for index := 0; index < len(text); index++ {
+		if text[index] != '\\' {
+			builder.WriteByte(text[index])
+			continue
+		}
+		decoded, consumed, stop, _ := parser.DecodeEscape(text[index+1:], parser.EscapeModeEcho)
+		if stop {
+			return builder.String(), true
+		}
+		builder.WriteString(decoded)
+		index += consumed
+	}
+	return builder.String(), false
+}
+
+// exitStatusOf converts the error returned by waiting for an external command
+// into the exit status reported by the shell.
+func exitStatusOf(err error) int {
+	if err == nil {
+		return 0
+	}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		if status, ok := exitError.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitError.ExitCode()
+	}
+	return 1
+}
+
+// builtinOption is a single option given to a builtin command.
+type builtinOption struct {
+	flag  byte
+	value string
+}
+
+// parseBuiltinOptions parses the leading single-letter options of a builtin.
+// Letters followed by ':' in spec take a value, either attached ("-p>") or as
+// the next argument. Parsing stops at "--" or the first non-option argument,
+// and the remaining arguments are returned.
+func parseBuiltinOptions(name string, args []string, spec string) ([]builtinOption, []string, error) {
+	var options []builtinOption
+	for len(args) > 0 {
+		arg := args[0]
+		if arg == "--" {
+			return options, args[1:], nil
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			break
+		}
+		args = args[1:]
+
+		for index := 1; index < len(arg); index++ {
+			flag := arg[index]
+			position := strings.IndexByte(spec, flag)
+			if position < 0 || flag == ':' {
+				return nil, nil, fmt.Errorf("%s: -%c: invalid option", name, flag)
+			}
+			if position+1 >= len(spec) || spec[position+1] != ':' {
+				options = append(options, builtinOption{flag: flag})
+				continue
+			}
+
+			if index+1 < len(arg) {
+				options = append(options, builtinOption{flag: flag, value: arg[index+1:]})
+			} else if len(args) > 0 {
+				options = append(options, builtinOption{flag: flag, value: args[0]})
+				args = args[1:]
+			} else {
+				return nil, nil, fmt.Errorf("%s: -%c: option requires an argument", name, flag)
+			}
+			break
+		}
+	}
+	return options, args, nil
+}
+
+// describeFileError returns the system error message of a file operation,
+// capitalized and without the operation and path that os.PathError adds. The
+// error of a redirection is prefixed with the file it names.
+func describeFileError(err error) string {
+	var redirectionError *shellio.RedirectionError
+	if errors.As(err, &redirectionError) {
+		return redirectionError.File + ": " + describeFileError(redirectionError.Err)
+	}
+	var pathError *os.PathError
+	if errors.As(err, &pathError) {
+		err = pathError.Err
+	}
+	message := err.Error()
+	if len(message) == 0 {
+		return message
+	}
+	return strings.ToUpper(message[:1]) + message[1:]
+}
@@ -29,17 +29,16 @@ func (h *CommandHistory) printAll(io shellio.IO) {
 }
 
 func (h *CommandHistory) printLast(limit int, io shellio.IO) {
-	startIndex := len(h.commandList) - limit
+	startIndex := max(len(h.commandList)-limit, 0)
 	for i := startIndex; i < len(h.commandList); i++ {
 		fmt.Fprintf(io.OutputFile(), "    %d  %s\n", i+1, h.commandList[i])
 	}
 }
 
-func (h *CommandHistory) appendFromFile(historyFile string) {
+func (h *CommandHistory) appendFromFile(historyFile string) error {
 	file, err := os.Open(historyFile)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening history file: %v", err)
-		return
+		return err
 	}
 	defer file.Close()
 
@@ -48,20 +47,18 @@ func (h *CommandHistory) appendFromFile(historyFile string) {
 		line := scanner.Text()
 		h.add(line)
 	}
+	return scanner.Err()
 }
 
-func (h *CommandHistory) saveToFile(historyFile string) {
+func (h *CommandHistory) saveToFile(historyFile string) error {
 	historyBytes := []byte{}
 	for _, command := range h.commandList {
 		historyBytes = append(historyBytes, []byte(command+"\n")...)
 	}
-	if err := os.WriteFile(historyFile, historyBytes, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "error writing history file: %v", err)
-		return
-	}
+	return os.WriteFile(historyFile, historyBytes, 0644)
 }
 
-func (h *CommandHistory) appendToFile(historyFile string) {
+func (h *CommandHistory) appendToFile(historyFile string) error {
 	historyString := ""
 	lastAppendIndex := h.lastAppendIndex()
 	for i := lastAppendIndex + 1; i < len(h.commandList); i++ {
@@ -72,14 +69,12 @@ func (h *CommandHistory) appendToFile(historyFile string) {
 	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
 	file, err := os.OpenFile(historyFile, flags, 0644)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error opening file: %v", err)
-		return
+		return err
 	}
 	defer file.Close()
 
-	if _, err := file.WriteString(historyString); err != nil {
-		fmt.Fprintf(os.Stderr, "error writing history file: %v", err)
-	}
+	_, err = file.WriteString(historyString)
+	return err
 }
 
 func (h *CommandHistory) lastAppendIndex() int {
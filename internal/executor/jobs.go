@@ -0,0 +1,193 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// Job is a command running in the background, such as a coprocess.
+type Job struct {
+	id      int
+	pid     int // The process of the last command, or 0 if there is none
+	command string
+	status  int
+	done    chan struct{}
+	// files are the descriptors the shell holds for the job, which commands
+	// do not inherit.
+	files []*os.File
+	// release frees what the job holds once it is removed from the table.
+	release func()
+}
+
+func (j *Job) isDone() bool {
+	select {
+	case <-j.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// state describes the job as listed by jobs.
+func (j *Job) state() string {
+	switch {
+	case !j.isDone():
+		return "Running"
+	case j.status == 0:
+		return "Done"
+	default:
+		return fmt.Sprintf("Exit %d", j.status)
+	}
+}
+
+// JobTable holds the jobs of the shell, numbered from 1 in the order they
+// were started.
+type JobTable struct {
+	jobs []*Job
+}
+
+// lastBackgroundPID is the process ID of the most recent job, which $!
+// expands to.
+var lastBackgroundPID int
+
+// add adds a job to the table. The job is finished once wait returns, which
+// happens in the background.
+func (t *JobTable) add(command string, pid int, files []*os.File, wait func() int, release func()) *Job {
+	id := 1
+	if len(t.jobs) > 0 {
+		id = t.jobs[len(t.jobs)-1].id + 1
+	}
+	job := &Job{id: id, pid: pid, command: command, done: make(chan struct{}), files: files, release: release}
+	go func() {
+		job.status = wait()
+		close(job.done)
+	}()
+
+	t.jobs = append(t.jobs, job)
+	if pid != 0 {
+		lastBackgroundPID = pid
+	}
+	return job
+}
+
+// remove takes a finished job out of the table.
+func (t *JobTable) remove(job *Job) {
+	t.jobs = slices.DeleteFunc(t.jobs, func(other *Job) bool { return other == job })
+	job.release()
+}
+
+// holds reports whether file is one of the descriptors held for a job.
+func (t *JobTable) holds(file *os.File) bool {
+	for _, job := range t.jobs {
+		if slices.Contains(job.files, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// find returns the job named by a job spec of the form %N or by the process
+// ID of its last command.
+func (t *JobTable) find(spec string) (*Job, error) {
+	if number, isJobSpec := strings.CutPrefix(spec, "%"); isJobSpec {
+		id, err := strconv.Atoi(number)
+		if err == nil {
+			for _, job := range t.jobs {
+				if job.id == id {
+					return job, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("%s: no such job", spec)
+	}
+
+	pid, err := strconv.Atoi(spec)
+	if err != nil || pid <= 0 {
+		return nil, fmt.Errorf("`%s': not a pid or valid job spec", spec)
+	}
+	for _, job := range t.jobs {
+		if job.pid == pid {
+			return job, nil
+		}
+	}
+	return nil, fmt.Errorf("pid %d is not a child of this shell", pid)
+}
+
+func jobsCommand(args []string, io shellio.IO) int {
+	options, _, err := parseBuiltinOptions("jobs", args, "lp")
+	if err != nil {
+		return usageError(io, err, "jobs: usage: jobs [-lp]")
+	}
+	showPID, onlyPID := false, false
+	for _, option := range options {
+		switch option.flag {
+		case 'l':
+			showPID = true
+		case 'p':
+			onlyPID = true
+		}
+	}
+
+	var finished []*Job
+	for index, job := range jobs.jobs {
+		if job.isDone() {
+			finished = append(finished, job)
+		}
+		if onlyPID {
+			fmt.Fprintln(io.OutputFile(), job.pid)
+			continue
+		}
+
+		// '+' marks the current job and '-' the one before it.
+		marker := ' '
+		switch index {
+		case len(jobs.jobs) - 1:
+			marker = '+'
+		case len(jobs.jobs) - 2:
+			marker = '-'
+		}
+		if showPID {
+			fmt.Fprintf(io.OutputFile(), "[%d]%c %d %-24s%s &\n", job.id, marker, job.pid, job.state(), job.command)
+		} else {
+			fmt.Fprintf(io.OutputFile(), "[%d]%c  %-24s%s &\n", job.id, marker, job.state(), job.command)
+		}
+	}
+
+	// Like any other notification, a finished job is only reported once.
+	for _, job := range finished {
+		jobs.remove(job)
+	}
+	return 0
+}
+
+// waitCommand waits for the given jobs, named by job spec or process ID, and
+// returns the exit status of the last one. Without arguments it waits for
+// every job and returns 0.
+func waitCommand(args []string, io shellio.IO) int {
+	if len(args) == 0 {
+		for len(jobs.jobs) > 0 {
+			job := jobs.jobs[0]
+			<-job.done
+			jobs.remove(job)
+		}
+		return 0
+	}
+
+	status := 0
+	for _, spec := range args {
+		job, err := jobs.find(spec)
+		if err != nil {
+			status = shellError(io, 127, "wait: %v", err)
+			continue
+		}
+		<-job.done
+		jobs.remove(job)
+		status = job.status
+	}
+	return status
+}
@@ -0,0 +1,128 @@
+package executor
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// ShellOptions holds the options toggled with the shopt builtin.
+type ShellOptions struct {
+	enabled map[string]bool
+}
+
+// shellOptionNames lists the options shopt knows about, in the order they
+// are printed.
+var shellOptionNames = []string{
+	"dotglob",
+	"extglob",
+	"failglob",
+	"globstar",
+	"nocaseglob",
+	"nullglob",
+}
+
+// shellOptions starts with every option off, as in bash. They are usually
+// turned on with shopt in the rc file.
+var shellOptions = ShellOptions{
+	enabled: map[string]bool{},
+}
+
+func (o *ShellOptions) isEnabled(name string) bool {
+	return o.enabled[name]
+}
+
+func (o *ShellOptions) set(name string, isEnabled bool) {
+	o.enabled[name] = isEnabled
+}
+
+// ShellOptionEnabled reports whether a shopt option is set. It lets file name
+// completion follow the same rules as pathname expansion.
+func ShellOptionEnabled(name string) bool {
+	return shellOptions.isEnabled(name)
+}
+
+func shoptCommand(args []string, io shellio.IO) int {
+	options, names, err := parseBuiltinOptions("shopt", args, "supq")
+	if err != nil {
+		return usageError(io, err, "shopt: usage: shopt [-pqsu] [optname ...]")
+	}
+
+	isSetting, isUnsetting, isReusable, isQuiet := false, false, false, false
+	for _, option := range options {
+		switch option.flag {
+		case 's':
+			isSetting = true
+		case 'u':
+			isUnsetting = true
+		case 'p':
+			isReusable = true
+		case 'q':
+			isQuiet = true
+		}
+	}
+	if isSetting && isUnsetting {
+		return shellError(io, 1, "shopt: cannot set and unset shell options simultaneously")
+	}
+
+	status := 0
+	for _, name := range names {
+		if !slices.Contains(shellOptionNames, name) {
+			status = shellError(io, 1, "shopt: %s: invalid shell option name", name)
+		}
+	}
+	if status != 0 {
+		return status
+	}
+
+	if isSetting || isUnsetting {
+		for _, name := range names {
+			shellOptions.set(name, isSetting)
+		}
+		if len(names) > 0 {
+			return 0
+		}
+	}
+
+	// When listing every option, the status does not depend on their state.
+	isListingAll := len(names) == 0
+	if isListingAll {
+		for _, name := range shellOptionNames {
+			// With -s or -u alone, only the options in that state are listed.
+			if (isSetting || isUnsetting) && shellOptions.isEnabled(name) != isSetting {
+				continue
+			}
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		isEnabled := shellOptions.isEnabled(name)
+		if !isEnabled && !isListingAll {
+			status = 1
+		}
+		if isQuiet {
+			continue
+		}
+		printShellOption(name, isEnabled, isReusable, io)
+	}
+	return status
+}
+
+func printShellOption(name string, isEnabled bool, isReusable bool, io shellio.IO) {
+	if isReusable {
+		flag := "-u"
+		if isEnabled {
+			flag = "-s"
+		}
+		fmt.Fprintf(io.OutputFile(), "shopt %s %s\n", flag, name)
+		return
+	}
+
+	state := "off"
+	if isEnabled {
+		state = "on"
+	}
+	fmt.Fprintf(io.OutputFile(), "%-15s\t%s\n", name, state)
+}
@@ -2,95 +2,197 @@ package executor
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
 	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
 )
 
 type PipelineRunner struct {
-	finalShellIO            shellio.IO
-	parsedCommands          [][]string
+	parsedCommands          []parser.Command
+	parentIO                shellio.IO
 	pipes                   [][2]*os.File
 	runningExternalCommands []*exec.Cmd
+	processSubstitutions    processSubstitutions
+	lastCommand             *exec.Cmd
+	lastExitStatus          int
 }
 
-func newPipelineRunner(parsedCommands [][]string, finalShellIO shellio.IO) *PipelineRunner {
+func newPipelineRunner(parsedCommands []parser.Command, parentIO shellio.IO) *PipelineRunner {
 	numCommands := len(parsedCommands)
 	pipes, err := initializePipes(numCommands - 1)
 	if err != nil {
-		fmt.Fprintln(finalShellIO.ErrorFile(), err)
+		shellError(parentIO, 1, "%v", err)
 		return nil
 	}
 	var runningExternalCommands []*exec.Cmd
 	return &PipelineRunner{
-		finalShellIO:            finalShellIO,
 		parsedCommands:          parsedCommands,
+		parentIO:                parentIO,
 		pipes:                   pipes,
 		runningExternalCommands: runningExternalCommands,
 	}
 }
 
-func (pr *PipelineRunner) run() {
+// run executes every stage of the pipeline and returns the exit status of
+// the last one.
+func (pr *PipelineRunner) run() int {
+	pr.start()
+	return pr.wait()
+}
+
+// start runs the builtins of the pipeline and starts its external commands
+// without waiting for them to finish.
+func (pr *PipelineRunner) start() {
 	for i, commandDef := range pr.parsedCommands {
-		if len(commandDef) == 0 {
-			fmt.Fprintln(pr.finalShellIO.ErrorFile(), "shell: error, empty command in pipeline")
-			pr.cleanupPipelineResources()
+		if len(commandDef.Args) == 0 {
+			pr.lastCommand = nil
+			pr.lastExitStatus = shellError(pr.parentIO, 1, "empty command in pipeline")
 			return
 		}
 
 		currentStdin, currentStdout := pr.determineStageIO(i, len(pr.parsedCommands))
-		command, err := pr.executePipelineStage(commandDef, currentStdin, currentStdout, pr.finalShellIO.ErrorFile())
-		if err != nil {
-			fmt.Fprintln(pr.finalShellIO.ErrorFile(), err)
-			pr.cleanupPipelineResources()
-		}
-
-		if command != nil {
-			pr.runningExternalCommands = append(pr.runningExternalCommands, command)
-		}
+		pr.lastCommand = nil
+		pipeIO := shellio.WithFile(shellio.WithFile(pr.parentIO, 0, currentStdin), 1, currentStdout)
+		pr.lastExitStatus = pr.runStage(commandDef, pipeIO, i)
+		pr.closeStagePipes(i)
 	}
+}
 
+// wait waits for the external commands started by start and returns the exit
+// status of the last stage.
+func (pr *PipelineRunner) wait() int {
 	pr.cleanupPipelineResources()
+	return pr.lastExitStatus
+}
+
+// runStage expands a stage of the pipeline and runs it. External commands
+// are left running and waited for once every stage has started. Like every
+// stage, one made only of assignments does not change the shell's variables.
+func (pr *PipelineRunner) runStage(commandDef parser.Command, pipeIO shellio.IO, stageIndex int) int {
+	// The process substitutions of the stage are cleaned up along with the
+	// rest of the pipeline.
+	var substitutions processSubstitutions
+	defer func() {
+		pr.processSubstitutions = append(pr.processSubstitutions, substitutions...)
+	}()
+
+	assignments, words := splitAssignments(commandDef.Args)
+	args, err := expandWords(words, &substitutions)
+	if err != nil {
+		return shellError(pipeIO, 1, "%v", err)
+	}
+
+	redirections, err := expandRedirections(commandDef.Redirections, &substitutions)
+	if err != nil {
+		return shellError(pipeIO, 1, "%v", err)
+	}
+	stageIO, err := shellio.OpenIo(redirections, pipeIO)
+	if err != nil {
+		return shellError(pipeIO, 1, "%s", describeFileError(err))
+	}
+	defer stageIO.Close()
+
+	restore, err := exportTemporarily(assignments, &substitutions)
+	if err != nil {
+		return shellError(stageIO, 1, "%v", err)
+	}
+	defer restore()
+
+	command, status, err := pr.executePipelineStage(args, stageIO, stageIndex, substitutions.extraFiles(stageIO))
+	if err != nil {
+		shellError(stageIO, status, "%v", err)
+	}
+	if command != nil {
+		pr.runningExternalCommands = append(pr.runningExternalCommands, command)
+	}
+	pr.lastCommand = command
+	return status
 }
 
-func (pr *PipelineRunner) executePipelineStage(commandDef []string, stdin, stdout, stderr *os.File) (*exec.Cmd, error) {
+// executePipelineStage runs a builtin to completion or starts an external
+// command. The returned status is only meaningful when no command is returned.
+func (pr *PipelineRunner) executePipelineStage(commandDef []string, stageIO shellio.IO, stageIndex int, extraFiles []*os.File) (*exec.Cmd, int, error) {
+	commandDef, isBuiltinRequired := unwrapCommand(commandDef)
+	// Every word of the stage may have expanded to nothing.
+	if len(commandDef) == 0 {
+		return nil, 0, nil
+	}
 	commandName, commandArgs := commandDef[0], commandDef[1:]
 	if builtinCommandExecutor, isBuiltinCommand := builtinCommands[commandName]; isBuiltinCommand {
-		builtinIO := shellio.NewIO(stdout, stderr)
-		builtinCommandExecutor(commandArgs, builtinIO)
-		return nil, nil
+		return nil, pr.runBuiltinStage(builtinCommandExecutor, commandArgs, stageIO, stageIndex), nil
+	}
+	if isBuiltinRequired {
+		return nil, 1, fmt.Errorf("builtin: %s: not a shell builtin", commandName)
 	}
 
-	externalCommand := exec.Command(commandName, commandArgs...)
-	externalCommand.Stdin = stdin
-	externalCommand.Stdout = stdout
-	externalCommand.Stderr = stderr
+	path, status, err := findCommand(commandName)
+	if err != nil {
+		return nil, status, fmt.Errorf("%s: %v", commandName, err)
+	}
+	externalCommand, err := startExternalCommand(path, commandDef, stageIO, extraFiles)
+	if err != nil {
+		return nil, 126, fmt.Errorf("%s: %s", commandName, describeFileError(err))
+	}
 
-	if err := externalCommand.Start(); err != nil {
-		return nil, fmt.Errorf("shell: error starting command %s: %v", commandName, err)
+	return externalCommand, 0, nil
+}
+
+// runBuiltinStage runs a builtin stage to completion. The stage after it has
+// not started yet, so output written to its pipe is kept in a temporary file
+// instead, which that stage reads in place of the pipe. A pipe would fill up
+// and block the builtin before anything reads it.
+func (pr *PipelineRunner) runBuiltinStage(builtin BuiltinCommandExecutor, args []string, stageIO shellio.IO, stageIndex int) int {
+	if stageIndex == len(pr.pipes) || stageIO.OutputFile() != pr.pipes[stageIndex][1] {
+		return builtin(args, stageIO)
 	}
 
-	return externalCommand, nil
+	buffer, err := os.CreateTemp("", "shell-pipeline-")
+	if err != nil {
+		return shellError(stageIO, 1, "cannot buffer pipeline output: %v", err)
+	}
+	os.Remove(buffer.Name())
+
+	status := builtin(args, shellio.WithFile(stageIO, 1, buffer))
+	buffer.Seek(0, io.SeekStart)
+	pr.pipes[stageIndex][0].Close()
+	pr.pipes[stageIndex][0] = buffer
+	return status
 }
 
 func (pr *PipelineRunner) determineStageIO(commandIndex, numTotalCommands int) (stdin, stdout *os.File) {
 	// Determine Stdin
 	if commandIndex == 0 {
-		stdin = os.Stdin
+		stdin = pr.parentIO.InputFile()
 	} else {
 		stdin = pr.pipes[commandIndex-1][0]
 	}
 
 	// Determine Stdout
 	if commandIndex == numTotalCommands-1 {
-		stdout = pr.finalShellIO.OutputFile()
+		stdout = pr.parentIO.OutputFile()
 	} else {
 		stdout = pr.pipes[commandIndex][1]
 	}
 	return stdin, stdout
 }
 
+// closeStagePipes closes the shell's copies of the pipe ends used by a stage
+// once it has started, so readers further down the pipeline see end of file
+// when the writer is done.
+func (pr *PipelineRunner) closeStagePipes(commandIndex int) {
+	if commandIndex > 0 {
+		pr.pipes[commandIndex-1][0].Close()
+		pr.pipes[commandIndex-1][0] = nil
+	}
+	if commandIndex < len(pr.pipes) {
+		pr.pipes[commandIndex][1].Close()
+		pr.pipes[commandIndex][1] = nil
+	}
+}
+
 // cleanupPipelineResources closes all pipes and waits for all running external commands to finish.
 func (pr *PipelineRunner) cleanupPipelineResources() {
 	for _, p := range pr.pipes {
@@ -103,6 +205,10 @@ func (pr *PipelineRunner) cleanupPipelineResources() {
 	}
 
 	for _, cmd := range pr.runningExternalCommands {
-		cmd.Wait()
+		err := cmd.Wait()
+		if cmd == pr.lastCommand {
+			pr.lastExitStatus = exitStatusOf(err)
+		}
 	}
+	pr.processSubstitutions.finish()
 }
@@ -0,0 +1,334 @@
+package executor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// printfFormatter expands a printf format string against a list of arguments.
+type printfFormatter struct {
+	arguments     []string
+	argumentIndex int
+	output        strings.Builder
+	errorIO       shellio.IO
+	isStopped     bool // set when a \c escape ends all output
+	hasFailed     bool // set when an argument or the format is invalid
+}
+
+func printfCommand(args []string, io shellio.IO) int {
+	variableName := ""
+	if len(args) >= 2 && args[0] == "-v" {
+		variableName = args[1]
+		if !isValidVariableName(variableName) {
+			return shellError(io, 2, "printf: `%s': not a valid identifier", variableName)
+		}
+		args = args[2:]
+	}
+	if len(args) > 0 && args[0] == "--" {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return shellError(io, 2, "printf: usage: printf [-v var] format [arguments]")
+	}
+
+	formatter := printfFormatter{arguments: args[1:], errorIO: io}
+	for {
+		if !formatter.formatOnce(args[0]) {
+			formatter.hasFailed = true
+			break
+		}
+		// The format is reused until all arguments are consumed, but only if
+		// it consumes arguments at all.
+		if formatter.isStopped || formatter.argumentIndex == 0 || formatter.argumentIndex >= len(formatter.arguments) {
+			break
+		}
+	}
+
+	if variableName != "" {
+		variables.set(variableName, formatter.output.String())
+	} else {
+		io.OutputFile().WriteString(formatter.output.String())
+	}
+	if formatter.hasFailed {
+		return 1
+	}
+	return 0
+}
+
+// formatOnce expands format a single time. It returns false if the format
+// contains an invalid directive.
+func (f *printfFormatter) formatOnce(format string) bool {
+	for index := 0; index < len(format) && !f.isStopped; index++ {
+		character := format[index]
+		switch character {
+		case '\\':
+			decoded, consumed, _ := f.decodeEscape(format[index+1:], parser.EscapeModeFormat)
+			f.output.WriteString(decoded)
+			index += consumed
+		case '%':
+			consumed, ok := f.formatDirective(format[index+1:])
+			if !ok {
+				return false
+			}
+			index += consumed
+		default:
+			f.output.WriteByte(character)
+		}
+	}
+	return true
+}
+
+// formatDirective expands the conversion that follows a '%' and returns the
+// number of bytes of the directive it consumed.
+func (f *printfFormatter) formatDirective(directive string) (int, bool) {
+	index := 0
+	flags := ""
+	for index < len(directive) && strings.IndexByte("-+ #0", directive[index]) >= 0 {
+		flags += string(directive[index])
+		index++
+	}
+
+	width := ""
+	if index < len(directive) && directive[index] == '*' {
+		value := f.nextInteger()
+		if value < 0 {
+			flags += "-"
+			value = -value
+		}
+		width = strconv.FormatInt(value, 10)
+		index++
+	} else {
+		for index < len(directive) && isDigit(directive[index]) {
+			width += string(directive[index])
+			index++
+		}
+	}
+
+	precision := ""
+	hasPrecision := false
+	if index < len(directive) && directive[index] == '.' {
+		hasPrecision = true
+		index++
+		if index < len(directive) && directive[index] == '*' {
+			precision = strconv.FormatInt(max(f.nextInteger(), 0), 10)
+			index++
+		} else {
+			for index < len(directive) && isDigit(directive[index]) {
+				precision += string(directive[index])
+				index++
+			}
+		}
+	}
+
+	if index >= len(directive) {
+		shellError(f.errorIO, 1, "printf: `%%': missing format character")
+		return index, false
+	}
+
+	spec := "%" + flags + width
+	if hasPrecision {
+		spec += "." + precision
+	}
+
+	conversion := directive[index]
+	switch conversion {
+	case '%':
+		f.output.WriteByte('%')
+	case 's':
+		fmt.Fprintf(&f.output, spec+"s", f.nextString())
+	case 'b':
+		argument := f.nextString()
+		var expanded strings.Builder
+		for position := 0; position < len(argument); position++ {
+			if argument[position] != '\\' {
+				expanded.WriteByte(argument[position])
+				continue
+			}
+			decoded, consumed, stop := f.decodeEscape(argument[position+1:], parser.EscapeModeArgument)
+			if stop {
+				f.isStopped = true
+				break
+			}
+			expanded.WriteString(decoded)
+			position += consumed
+		}
+		fmt.Fprintf(&f.output, spec+"s", expanded.String())
+	case 'q':
+		fmt.Fprintf(&f.output, spec+"s", shellQuote(f.nextString()))
+	case 'c':
+		argument := f.nextString()
+		character := ""
+		if len(argument) > 0 {
+			_, size := utf8.DecodeRuneInString(argument)
+			character = argument[:size]
+		}
+		fmt.Fprintf(&f.output, spec+"s", character)
+	case 'd', 'i':
+		fmt.Fprintf(&f.output, spec+"d", f.nextInteger())
+	case 'u':
+		fmt.Fprintf(&f.output, spec+"d", uint64(f.nextInteger()))
+	case 'o', 'x', 'X':
+		fmt.Fprintf(&f.output, spec+string(conversion), uint64(f.nextInteger()))
+	case 'f', 'F', 'e', 'E', 'g', 'G':
+		// Unlike C, Go's %g defaults to the shortest representation.
+		if !hasPrecision && (conversion == 'g' || conversion == 'G') {
+			spec += ".6"
+		}
+		if conversion == 'F' {
+			conversion = 'f'
+		}
+		fmt.Fprintf(&f.output, spec+string(conversion), f.nextFloat())
+	default:
+		shellError(f.errorIO, 1, "printf: `%c': invalid format character", conversion)
+		return index + 1, false
+	}
+
+	return index + 1, true
+}
+
+func (f *printfFormatter) nextString() string {
+	if f.argumentIndex >= len(f.arguments) {
+		return ""
+	}
+	argument := f.arguments[f.argumentIndex]
+	f.argumentIndex++
+	return argument
+}
+
+func (f *printfFormatter) nextInteger() int64 {
+	argument := f.nextString()
+	if value, ok := characterCode(argument); ok {
+		return value
+	}
+
+	trimmed := strings.TrimLeft(argument, " \t\n")
+	if trimmed == "" {
+		return 0
+	}
+	value, err := strconv.ParseInt(trimmed, 0, 64)
+	if err != nil {
+		shellError(f.errorIO, 1, "printf: %s: invalid number", argument)
+		f.hasFailed = true
+		value = parseIntegerPrefix(trimmed)
+	}
+	return value
+}
+
+func (f *printfFormatter) nextFloat() float64 {
+	argument := f.nextString()
+	if value, ok := characterCode(argument); ok {
+		return float64(value)
+	}
+
+	trimmed := strings.TrimSpace(argument)
+	if trimmed == "" {
+		return 0
+	}
+	value, err := strconv.ParseFloat(trimmed, 64)
+	if err != nil {
+		shellError(f.errorIO, 1, "printf: %s: invalid number", argument)
+		f.hasFailed = true
+		return float64(parseIntegerPrefix(trimmed))
+	}
+	return value
+}
+
+// characterCode handles numeric arguments written as 'c or "c, whose value
+// is the code point of the character that follows the quote.
+func characterCode(argument string) (int64, bool) {
+	if len(argument) < 2 || (argument[0] != '\'' && argument[0] != '"') {
+		return 0, false
+	}
+	character, _ := utf8.DecodeRuneInString(argument[1:])
+	return int64(character), true
+}
+
+// parseIntegerPrefix returns the value of the longest valid decimal prefix of
+// a malformed number, which is what printf prints alongside the error.
+func parseIntegerPrefix(number string) int64 {
+	end := 0
+	if end < len(number) && (number[end] == '-' || number[end] == '+') {
+		end++
+	}
+	for end < len(number) && isDigit(number[end]) {
+		end++
+	}
+	value, _ := strconv.ParseInt(number[:end], 10, 64)
+	return value
+}
+
+// decodeEscape decodes the escape sequence at the start of sequence, which
+// follows a backslash. It returns the decoded text, the number of bytes
+// consumed and whether a \c escape asked to stop producing output.
+func (f *printfFormatter) decodeEscape(sequence string, mode parser.EscapeMode) (string, int, bool) {
+	decoded, consumed, stop, err := parser.DecodeEscape(sequence, mode)
+	if err != nil && f.errorIO != nil {
+		shellError(f.errorIO, 1, "printf: %v", err)
+	}
+	return decoded, consumed, stop
+}
+
+func isDigit(character byte) bool {
+	return character >= '0' && character <= '9'
+}
+
+// shellQuote quotes text so that the shell reads it back as a single word.
+func shellQuote(text string) string {
+	if text == "" {
+		return "''"
+	}
+
+	for _, character := range text {
+		if character == utf8.RuneError || !unicode.IsPrint(character) {
+			return ansiCQuote(text)
+		}
+	}
+
+	var builder strings.Builder
+	for index := 0; index < len(text); index++ {
+		character := text[index]
+		isSpecial := strings.IndexByte(" \t\n'\"\\|&;()<>!{}*[?]^$`,", character) >= 0
+		if isSpecial || (index == 0 && (character == '~' || character == '#')) {
+			builder.WriteByte('\\')
+		}
+		builder.WriteByte(character)
+	}
+	return builder.String()
+}
+
+// ansiCQuote quotes text using the $'...' form, escaping control characters.
+func ansiCQuote(text string) string {
+	var builder strings.Builder
+	builder.WriteString("$'")
+	for index := 0; index < len(text); {
+		character, size := utf8.DecodeRuneInString(text[index:])
+		switch {
+		case character == '\n':
+			builder.WriteString("\\n")
+		case character == '\t':
+			builder.WriteString("\\t")
+		case character == '\r':
+			builder.WriteString("\\r")
+		case character == '\x1b':
+			builder.WriteString("\\E")
+		case character == '\'' || character == '\\':
+			builder.WriteByte('\\')
+			builder.WriteRune(character)
+		case character == utf8.RuneError && size == 1, !unicode.IsPrint(character):
+			for _, b := range []byte(text[index : index+size]) {
+				fmt.Fprintf(&builder, "\\%03o", b)
+			}
+		default:
+			builder.WriteString(text[index : index+size])
+		}
+		index += size
+	}
+	builder.WriteByte('\'')
+	return builder.String()
+}
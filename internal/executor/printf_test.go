@@ -0,0 +1,79 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// runPrintf runs printf with args and returns what it printed, which it
+// assigns to a variable with -v rather than writing to a file.
+func runPrintf(t *testing.T, args ...string) string {
+	t.Helper()
+	printfCommand(append([]string{"-v", "printfTestOutput"}, args...), shellio.NewIO(nil, nil, nil))
+	output, _ := variables.get("printfTestOutput")
+	return output
+}
+
+func TestPrintfReusesFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"no arguments", []string{`x\n`}, "x\n"},
+		{"missing argument", []string{`%s\n`}, "\n"},
+		{"one per directive", []string{`%s-%s\n`, "a", "b"}, "a-b\n"},
+		{"reused for extra arguments", []string{`%d\n`, "1", "2", "3"}, "1\n2\n3\n"},
+		{"last pass padded with empty arguments", []string{`%s-%s\n`, "a", "b", "c"}, "a-b\nc-\n"},
+		{"format without directives printed once", []string{`x`, "a", "b"}, "x"},
+		{"stopped by \\c", []string{`%b\n`, `a\tb\c`, "never"}, "a\tb"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := runPrintf(t, test.args...); got != test.want {
+				t.Errorf("printf %q = %q, want %q", test.args, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPrintfDirectives(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{`%5.2f|%-4s|%04d`, "3.14159", "ab", "7"}, " 3.14|ab  |0007"},
+		{[]string{`%x %o %c`, "255", "8", "hello"}, "ff 10 h"},
+		{[]string{`%*d|`, "5", "42"}, "   42|"},
+		{[]string{`%d`, "'A"}, "65"},
+		{[]string{`100%%`}, "100%"},
+	}
+	for _, test := range tests {
+		if got := runPrintf(t, test.args...); got != test.want {
+			t.Errorf("printf %q = %q, want %q", test.args, got, test.want)
+		}
+	}
+}
+
+func TestPrintfQuote(t *testing.T) {
+	tests := []struct {
+		argument string
+		want     string
+	}{
+		{"plain", "plain"},
+		{"", "''"},
+		{"a b", `a\ b`},
+		{"it's", `it\'s`},
+		{"~x", `\~x`},
+		{"a~x", "a~x"},
+		{"$HOME", `\$HOME`},
+		{"tab\t", `$'tab\t'`},
+		{"line\nbreak", `$'line\nbreak'`},
+	}
+	for _, test := range tests {
+		if got := runPrintf(t, "%q", test.argument); got != test.want {
+			t.Errorf("printf %%q %q = %q, want %q", test.argument, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// processSubstitution is a command started by <(command) or >(command). It
+// runs alongside the command whose word it appears in, which reaches it
+// through a /dev/fd path naming the shell's end of a pipe.
+type processSubstitution struct {
+	file     *os.File
+	subshell *exec.Cmd
+}
+
+// processSubstitutions are the process substitutions of a single command.
+// They are started while its words are expanded and cleaned up once it has
+// finished.
+type processSubstitutions []*processSubstitution
+
+// start runs command connected to a pipe and returns the path the other end
+// of the pipe can be opened by. The output of <(command) is read from the
+// path and the input of >(command) is written to it. Like a command
+// substitution, the command runs in a subshell.
+func (s *processSubstitutions) start(command string, kind parser.PartKind) (string, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("cannot make pipe for process substitution: %v", err)
+	}
+
+	shellEnd, commandEnd := reader, writer
+	commandIO := shellio.WithFile(&shellFiles, 1, writer)
+	if kind == parser.OutputProcessSubstitution {
+		shellEnd, commandEnd = writer, reader
+		commandIO = shellio.WithFile(&shellFiles, 0, reader)
+	}
+	shellEnd, err = moveDescriptor(shellEnd)
+	if err != nil {
+		commandEnd.Close()
+		return "", fmt.Errorf("cannot make pipe for process substitution: %v", err)
+	}
+
+	subshell, err := startSubshell(command, captureSubshellState(), commandIO, inheritedFiles(commandIO))
+	commandEnd.Close()
+	if err != nil {
+		shellEnd.Close()
+		return "", fmt.Errorf("process substitution: %v", err)
+	}
+
+	*s = append(*s, &processSubstitution{file: shellEnd, subshell: subshell})
+	return fmt.Sprintf("/dev/fd/%d", shellEnd.Fd()), nil
+}
+
+// extraFiles returns the files on descriptors 3 and above that an external
+// command started with io inherits. Besides those of io, they include the
+// pipes of the process substitutions, on the descriptors they have in the
+// shell so that their /dev/fd paths are valid in the command too.
+func (s processSubstitutions) extraFiles(io shellio.IO) []*os.File {
+	files := inheritedFiles(io)
+	for _, substitution := range s {
+		// Entry i of exec.Cmd.ExtraFiles becomes descriptor 3+i.
+		index := int(substitution.file.Fd()) - 3
+		for len(files) <= index {
+			files = append(files, nil)
+		}
+		files[index] = substitution.file
+	}
+	return files
+}
+
+// finish closes the shell's ends of the pipes and waits for the commands to
+// exit. A >(command) then sees the end of its input, while a <(command)
+// still writing gets an error, as nothing is reading any more.
+func (s *processSubstitutions) finish() {
+	for _, substitution := range *s {
+		substitution.file.Close()
+	}
+	for _, substitution := range *s {
+		substitution.subshell.Wait()
+	}
+}
@@ -0,0 +1,325 @@
+package executor
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+	"github.com/pkg/term/termios"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	readStatusEndOfFile = 1
+	readStatusTimeout   = 128 + int(unix.SIGALRM)
+	defaultIFS          = " \t\n"
+)
+
+// readCharacter is a byte of input read by the read builtin. Escaped
+// characters are never treated as field separators.
+type readCharacter struct {
+	value     byte
+	isEscaped bool
+}
+
+// readOptions holds the options given to the read builtin.
+type readOptions struct {
+	isRaw         bool
+	isSilent      bool
+	prompt        string
+	timeout       time.Duration
+	hasTimeout    bool
+	maxCharacters int
+	delimiter     byte
+	arrayName     string
+}
+
+func readCommand(args []string, io shellio.IO) int {
+	parsedOptions, names, err := parseBuiltinOptions("read", args, "rsp:t:n:d:a:")
+	if err != nil {
+		return usageError(io, err, "read: usage: read [-rs] [-a array] [-d delim] [-n nchars] [-p prompt] [-t timeout] [name ...]")
+	}
+
+	options := readOptions{maxCharacters: -1, delimiter: '\n'}
+	for _, option := range parsedOptions {
+		switch option.flag {
+		case 'r':
+			options.isRaw = true
+		case 's':
+			options.isSilent = true
+		case 'p':
+			options.prompt = option.value
+		case 't':
+			seconds, err := strconv.ParseFloat(option.value, 64)
+			if err != nil || seconds < 0 {
+				return shellError(io, 2, "read: %s: invalid timeout specification", option.value)
+			}
+			options.timeout = time.Duration(seconds * float64(time.Second))
+			options.hasTimeout = true
+		case 'n':
+			count, err := strconv.Atoi(option.value)
+			if err != nil || count < 0 {
+				return shellError(io, 2, "read: %s: invalid number", option.value)
+			}
+			options.maxCharacters = count
+		case 'd':
+			options.delimiter = 0
+			if len(option.value) > 0 {
+				options.delimiter = option.value[0]
+			}
+		case 'a':
+			options.arrayName = option.value
+		}
+	}
+
+	for _, name := range append(names, options.arrayName) {
+		if name != "" && !isValidVariableName(name) {
+			return shellError(io, 1, "read: `%s': not a valid identifier", name)
+		}
+	}
+
+	input := io.InputFile()
+	isTerminal := isTerminalFile(input)
+	if isTerminal && options.prompt != "" {
+		io.ErrorFile().WriteString(options.prompt)
+	}
+
+	// A timeout of zero only checks whether input is available.
+	if options.hasTimeout && options.timeout == 0 {
+		if ready, _ := waitForInput(input, time.Now()); ready {
+			return 0
+		}
+		return readStatusTimeout
+	}
+
+	if isTerminal {
+		restore, err := configureReadTerminal(input, options)
+		if err == nil {
+			defer restore()
+		}
+	}
+
+	characters, status := readInput(input, options)
+	assignReadFields(characters, names, options.arrayName)
+	return status
+}
+
+// readInput reads from input one byte at a time, so that no input meant for
+// later commands is consumed, until the delimiter, the character limit, a
+// timeout or the end of input.
+func readInput(input *os.File, options readOptions) ([]readCharacter, int) {
+	var deadline time.Time
+	if options.hasTimeout {
+		deadline = time.Now().Add(options.timeout)
+	}
+
+	var characters []readCharacter
+	buffer := make([]byte, 1)
+	count := 0
+	pendingContinuationBytes := 0
+	isEscaped := false
+
+	for options.maxCharacters < 0 || count < options.maxCharacters || pendingContinuationBytes > 0 {
+		if options.hasTimeout {
+			if ready, err := waitForInput(input, deadline); err != nil || !ready {
+				return characters, readStatusTimeout
+			}
+		}
+
+		n, err := input.Read(buffer)
+		if n == 0 || err != nil {
+			return characters, readStatusEndOfFile
+		}
+		character := buffer[0]
+
+		if pendingContinuationBytes > 0 && character&0xC0 == 0x80 {
+			pendingContinuationBytes--
+			characters = append(characters, readCharacter{value: character, isEscaped: isEscaped})
+			continue
+		}
+		pendingContinuationBytes = utf8ContinuationBytes(character)
+
+		if isEscaped {
+			isEscaped = false
+			// A backslash-newline pair continues the line.
+			if character == '\n' {
+				continue
+			}
+			characters = append(characters, readCharacter{value: character, isEscaped: true})
+			count++
+			continue
+		}
+		if character == options.delimiter {
+			break
+		}
+		if character == '\\' && !options.isRaw {
+			isEscaped = true
+			continue
+		}
+		characters = append(characters, readCharacter{value: character})
+		count++
+	}
+
+	return characters, 0
+}
+
+// utf8ContinuationBytes returns how many continuation bytes follow a UTF-8
+// lead byte, so that -n counts characters rather than bytes.
+func utf8ContinuationBytes(lead byte) int {
+	switch {
+	case lead&0xE0 == 0xC0:
+		return 1
+	case lead&0xF0 == 0xE0:
+		return 2
+	case lead&0xF8 == 0xF0:
+		return 3
+	}
+	return 0
+}
+
+func assignReadFields(characters []readCharacter, names []string, arrayName string) {
+	ifs, isSet := variables.get("IFS")
+	if !isSet {
+		ifs = defaultIFS
+	}
+
+	if arrayName != "" {
+		variables.setArray(arrayName, splitReadFields(characters, ifs, 0))
+	}
+	if len(names) == 0 {
+		if arrayName == "" {
+			variables.set("REPLY", readCharactersString(characters))
+		}
+		return
+	}
+
+	for index, field := range splitReadFields(characters, ifs, len(names)) {
+		variables.set(names[index], field)
+	}
+}
+
+// splitReadFields splits a line into fields using the characters of ifs. If
+// count is positive, exactly count fields are returned and the last one
+// receives the remainder of the line.
+func splitReadFields(characters []readCharacter, ifs string, count int) []string {
+	isSeparator := func(character readCharacter) bool {
+		return !character.isEscaped && strings.IndexByte(ifs, character.value) >= 0
+	}
+	isWhitespaceSeparator := func(character readCharacter) bool {
+		return isSeparator(character) && strings.IndexByte(defaultIFS, character.value) >= 0
+	}
+
+	start, end := 0, len(characters)
+	for start < end && isWhitespaceSeparator(characters[start]) {
+		start++
+	}
+	for end > start && isWhitespaceSeparator(characters[end-1]) {
+		end--
+	}
+
+	var fields []string
+	index := start
+	for index < end {
+		if count > 0 && len(fields) == count-1 {
+			rest := characters[index:end]
+			// A single trailing delimiter after the last field is dropped.
+			if len(rest) > 0 && isSeparator(rest[len(rest)-1]) {
+				hasOtherSeparator := false
+				for _, character := range rest[:len(rest)-1] {
+					if isSeparator(character) {
+						hasOtherSeparator = true
+						break
+					}
+				}
+				if !hasOtherSeparator {
+					rest = rest[:len(rest)-1]
+				}
+			}
+			fields = append(fields, readCharactersString(rest))
+			break
+		}
+
+		fieldStart := index
+		for index < end && !isSeparator(characters[index]) {
+			index++
+		}
+		fields = append(fields, readCharactersString(characters[fieldStart:index]))
+
+		// Consume the separator: surrounding IFS whitespace and at most one
+		// non-whitespace IFS character.
+		for index < end && isWhitespaceSeparator(characters[index]) {
+			index++
+		}
+		if index < end && isSeparator(characters[index]) {
+			index++
+			for index < end && isWhitespaceSeparator(characters[index]) {
+				index++
+			}
+		}
+	}
+
+	for count > 0 && len(fields) < count {
+		fields = append(fields, "")
+	}
+	return fields
+}
+
+func readCharactersString(characters []readCharacter) string {
+	bytes := make([]byte, len(characters))
+	for index, character := range characters {
+		bytes[index] = character.value
+	}
+	return string(bytes)
+}
+
+// waitForInput waits until input is readable or the deadline passes.
+func waitForInput(input *os.File, deadline time.Time) (bool, error) {
+	for {
+		timeout := max(time.Until(deadline).Milliseconds(), 0)
+		fds := []unix.PollFd{{Fd: int32(input.Fd()), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(timeout))
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		return n > 0, nil
+	}
+}
+
+func isTerminalFile(file *os.File) bool {
+	var attributes unix.Termios
+	return termios.Tcgetattr(file.Fd(), &attributes) == nil
+}
+
+// configureReadTerminal turns off echo for -s and canonical mode when input
+// should be returned before a newline is typed. The returned function
+// restores the previous settings.
+func configureReadTerminal(input *os.File, options readOptions) (func(), error) {
+	fd := input.Fd()
+	var previous unix.Termios
+	if err := termios.Tcgetattr(fd, &previous); err != nil {
+		return nil, err
+	}
+
+	var new = unix.Termios(previous)
+	if options.isSilent {
+		new.Lflag &^= unix.ECHO
+	}
+	if options.maxCharacters >= 0 || options.delimiter != '\n' {
+		new.Lflag &^= unix.ICANON
+		new.Cc[unix.VMIN] = 1
+		new.Cc[unix.VTIME] = 0
+	}
+	if err := termios.Tcsetattr(fd, termios.TCSANOW, &new); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		termios.Tcsetattr(fd, termios.TCSANOW, &previous)
+	}, nil
+}
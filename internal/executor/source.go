@@ -0,0 +1,141 @@
+package executor
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+const (
+	loginProfileFileName  = ".shell_profile"
+	interactiveRcFileName = ".shellrc"
+)
+
+func sourceCommand(args []string, io shellio.IO) int {
+	if len(args) == 0 {
+		return usageError(io, errors.New("source: filename argument required"), "source: usage: source filename [arguments]")
+	}
+
+	fileName := findSourceFile(args[0])
+	status, err := sourceFile(fileName, io)
+	if err != nil {
+		return shellError(io, 1, "source: %s: %s", args[0], describeFileError(err))
+	}
+	return status
+}
+
+// sourceFile runs every line of a file in the current shell context, so the
+// file can change variables and other shell state. A command may continue
+// over several lines. Diagnostics are reported with the file name and line
+// number, and syntax errors do not stop the remaining lines from running.
+// Descriptors changed by exec are seen by the lines that follow it. It
+// returns the exit status of the last command.
+func sourceFile(fileName string, io shellio.IO) (int, error) {
+	file, err := os.Open(fileName)
+	if err != nil {
+		return 1, err
+	}
+	defer file.Close()
+	io = followShellFiles(io)
+
+	savedPosition := sourcePosition
+	defer func() { sourcePosition = savedPosition }()
+	sourcePosition.name = fileName
+
+	lastExitStatus = 0
+	lineNumber := 0
+	firstLine := 0
+	command := ""
+	isContinued := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		if isContinued {
+			command += "\n" + scanner.Text()
+		} else {
+			command = scanner.Text()
+			firstLine = lineNumber
+		}
+
+		// A command that is not complete yet continues on the next line.
+		isContinued = NeedsMoreInput(command)
+		if isContinued {
+			continue
+		}
+		sourcePosition.line = firstLine
+		if err := executeLine(command, io); err != nil {
+			reportLineError(io, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 1, err
+	}
+	if isContinued {
+		sourcePosition.line = firstLine
+		if err := executeLine(command, io); err != nil {
+			reportLineError(io, err)
+		}
+	}
+	return lastExitStatus, nil
+}
+
+// findSourceFile resolves a file name without a slash by searching $PATH
+// before falling back to the current directory.
+func findSourceFile(fileName string) string {
+	if strings.Contains(fileName, "/") {
+		return fileName
+	}
+
+	PATH := os.Getenv("PATH")
+	for dir := range strings.SplitSeq(PATH, string(os.PathListSeparator)) {
+		fullPath := path.Join(dir, fileName)
+		if fileInfo, err := os.Stat(fullPath); err == nil && fileInfo.Mode().IsRegular() {
+			return fullPath
+		}
+	}
+	return fileName
+}
+
+// loadStartupFiles sources the login profile when the shell is started as a
+// login shell and the interactive rc file when standard input is a terminal.
+func loadStartupFiles() {
+	HOME := os.Getenv("HOME")
+	if len(HOME) == 0 {
+		return
+	}
+
+	startupIO := &shellFiles
+	if isLoginShell() {
+		sourceStartupFile(path.Join(HOME, loginProfileFileName), startupIO)
+	}
+	if isTerminalFile(os.Stdin) {
+		sourceStartupFile(path.Join(HOME, interactiveRcFileName), startupIO)
+	}
+}
+
+func sourceStartupFile(fileName string, io shellio.IO) {
+	if _, err := os.Stat(fileName); err != nil {
+		return
+	}
+	if _, err := sourceFile(fileName, io); err != nil {
+		shellError(io, 1, "%s: %s", fileName, describeFileError(err))
+	}
+}
+
+// isLoginShell reports whether the shell was started as a login shell, either
+// with a leading '-' in its name or with the -l/--login option.
+func isLoginShell() bool {
+	if strings.HasPrefix(os.Args[0], "-") {
+		return true
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "-l" || arg == "--login" {
+			return true
+		}
+	}
+	return false
+}
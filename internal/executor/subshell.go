@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// subshellState is the state a subshell starts from, which is a copy of the
+// state of the shell that started it. Changes the subshell makes, such as
+// assignments, cd or exit, stay within it.
+type subshellState struct {
+	Variables         map[string]string
+	Arrays            map[string][]string
+	Aliases           map[string]string
+	Options           map[string]bool
+	DirectoryStack    []string
+	History           []string
+	LastExitStatus    int
+	LastBackgroundPID int
+	SourceName        string
+	SourceLine        int
+	// Descriptors are the descriptors above 2 the subshell inherits.
+	Descriptors []int
+}
+
+// captureSubshellState copies the state of the shell for a subshell.
+func captureSubshellState() subshellState {
+	return subshellState{
+		Variables:         variables.values,
+		Arrays:            variables.arrays,
+		Aliases:           aliases.aliases,
+		Options:           shellOptions.enabled,
+		DirectoryStack:    directoryStack.entries,
+		History:           history.commandList,
+		LastExitStatus:    lastExitStatus,
+		LastBackgroundPID: lastBackgroundPID,
+		SourceName:        sourcePosition.name,
+		SourceLine:        sourcePosition.line,
+	}
+}
+
+// restore makes the state the state of this shell.
+func (s subshellState) restore() {
+	variables.values = s.Variables
+	variables.arrays = s.Arrays
+	aliases.aliases = s.Aliases
+	if s.Options != nil {
+		shellOptions.enabled = s.Options
+	}
+	directoryStack.entries = s.DirectoryStack
+	history.commandList = s.History
+	lastExitStatus = s.LastExitStatus
+	lastBackgroundPID = s.LastBackgroundPID
+	sourcePosition.name = s.SourceName
+	sourcePosition.line = s.SourceLine
+	for _, descriptor := range s.Descriptors {
+		shellFiles.SetFile(descriptor, os.NewFile(uintptr(descriptor), fmt.Sprintf("/dev/fd/%d", descriptor)))
+	}
+}
+
+// subshellOption is the option a subshell is started with, followed by the
+// descriptor its state is read from.
+const subshellOption = "--subshell"
+
+// startSubshell starts a copy of the shell as a child process, which runs
+// command with the files of io and extraFiles, as exec.Cmd.ExtraFiles
+// expects them. The state of the shell is handed over through a pipe, on the
+// descriptor after the extra files.
+func startSubshell(command string, state subshellState, io shellio.IO, extraFiles []*os.File) (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	for index, file := range extraFiles {
+		if file != nil {
+			state.Descriptors = append(state.Descriptors, 3+index)
+		}
+	}
+	encodedState, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	stateDescriptor := 3 + len(extraFiles)
+	args := []string{os.Args[0], subshellOption, strconv.Itoa(stateDescriptor), "-c", command}
+	cmd := newExternalCommand(executable, args, io, append(extraFiles, reader))
+	if err := cmd.Start(); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	// The subshell reads its state before running anything, but the state
+	// may not fit in the pipe, so it is written while the subshell starts.
+	go func() {
+		writer.Write(encodedState)
+		writer.Close()
+	}()
+	return cmd, nil
+}
+
+// RunSubshell runs a subshell started by another shell, taking over the
+// state handed over on descriptor, and returns the exit status of command.
+func RunSubshell(descriptor int, command string) int {
+	stateFile := os.NewFile(uintptr(descriptor), "subshell state")
+	var state subshellState
+	err := json.NewDecoder(stateFile).Decode(&state)
+	stateFile.Close()
+	if err != nil {
+		return shellError(&shellFiles, 2, "cannot start subshell: %v", err)
+	}
+	state.restore()
+	return RunCommand(command)
+}
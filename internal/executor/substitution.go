@@ -0,0 +1,351 @@
+package executor
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// substitutionStatus is the exit status of the last command substitution. It
+// becomes the status of a command that consists only of assignments or whose
+// words all expanded to nothing.
+var substitutionStatus int
+
+// expandSubstitutions replaces the parameter expansions and command
+// substitutions of a word with their values. The parts keep their kind and
+// quoting, so that field splitting can tell which text came from an unquoted
+// expansion. Process substitutions are started and added to substitutions.
+func expandSubstitutions(word parser.Word, substitutions *processSubstitutions) (parser.Word, error) {
+	expanded := make(parser.Word, 0, len(word))
+	for _, part := range word {
+		switch part.Kind {
+		case parser.Parameter:
+			value, err := expandParameter(part.Text, part.Quoting)
+			if err != nil {
+				return nil, err
+			}
+			part.Text = value
+		case parser.CommandSubstitution:
+			output, err := substituteCommand(part.Text)
+			if err != nil {
+				return nil, err
+			}
+			part.Text = output
+		case parser.InputProcessSubstitution, parser.OutputProcessSubstitution:
+			path, err := substitutions.start(part.Text, part.Kind)
+			if err != nil {
+				return nil, err
+			}
+			// The path is never split or matched against file names.
+			part.Text, part.Quoting = path, parser.SingleQuoted
+		}
+		expanded = append(expanded, part)
+	}
+	return expanded, nil
+}
+
+// expandParameter returns the value of a parameter expansion that appeared
+// with the given quoting. Besides plain names, ${#name} gives the length of
+// the value, ${name:offset} and ${name:offset:length} select part of it and
+// ${name:-word} and the like substitute a default. Lengths and offsets count
+// characters rather than bytes, with each invalid byte counting as one.
+func expandParameter(expression string, quoting parser.Quoting) (string, error) {
+	badSubstitution := fmt.Errorf("${%s}: bad substitution", expression)
+
+	if name, operator, word, hasOperator := cutParameterOperator(expression); hasOperator {
+		return expandParameterOperator(name, operator, word, quoting, badSubstitution)
+	}
+
+	if name, isLength := strings.CutPrefix(expression, "#"); isLength && name != "" {
+		value, ok := lookupParameter(name)
+		if !ok {
+			return "", badSubstitution
+		}
+		return strconv.Itoa(utf8.RuneCountInString(value)), nil
+	}
+
+	if name, arguments, isSubstring := strings.Cut(expression, ":"); isSubstring {
+		value, ok := lookupParameter(name)
+		if !ok {
+			return "", badSubstitution
+		}
+		return substringParameter(value, arguments, badSubstitution)
+	}
+
+	value, ok := lookupParameter(expression)
+	if !ok {
+		return "", badSubstitution
+	}
+	return value, nil
+}
+
+// lookupParameter returns the value of a variable, an array element such as
+// NAME[1] or a special parameter. Unset variables expand to nothing.
+// Positional parameters are not supported yet, so they are always empty. It
+// reports false if name is not a parameter.
+func lookupParameter(name string) (string, bool) {
+	switch name {
+	case "?":
+		return strconv.Itoa(lastExitStatus), true
+	case "$":
+		return strconv.Itoa(os.Getpid()), true
+	case "0":
+		return os.Args[0], true
+	case "#":
+		return "0", true
+	case "!":
+		if lastBackgroundPID == 0 {
+			return "", true
+		}
+		return strconv.Itoa(lastBackgroundPID), true
+	case "@", "*", "-":
+		return "", true
+	}
+
+	if arrayName, subscript, isElement := strings.Cut(name, "["); isElement {
+		index, err := strconv.Atoi(strings.TrimSuffix(subscript, "]"))
+		if !strings.HasSuffix(subscript, "]") || err != nil || index < 0 || !isValidVariableName(arrayName) {
+			return "", false
+		}
+		// A variable that is not an array is its own element 0.
+		if array, isArray := variables.getArray(arrayName); isArray {
+			if index < len(array) {
+				return array[index], true
+			}
+			return "", true
+		}
+		if index == 0 {
+			value, _ := variables.get(arrayName)
+			return value, true
+		}
+		return "", true
+	}
+
+	if _, err := strconv.Atoi(name); err == nil {
+		return "", true
+	}
+	if !isValidVariableName(name) {
+		return "", false
+	}
+	value, _ := variables.get(name)
+	return value, true
+}
+
+// isParameterSet reports whether a parameter has a value, even an empty one.
+// Positional parameters are not supported yet, so they are never set, and $!
+// is only set once a job has been started in the background.
+func isParameterSet(name string) bool {
+	switch name {
+	case "!":
+		return lastBackgroundPID != 0
+	case "@", "*":
+		return false
+	case "?", "$", "#", "-":
+		return true
+	}
+	if isDigit(name[0]) {
+		return name == "0"
+	}
+	if arrayName, subscript, isElement := strings.Cut(name, "["); isElement {
+		index, _ := strconv.Atoi(strings.TrimSuffix(subscript, "]"))
+		if array, isArray := variables.getArray(arrayName); isArray {
+			return index < len(array)
+		}
+		if index != 0 {
+			return false
+		}
+		name = arrayName
+	}
+	_, isSet := variables.get(name)
+	return isSet
+}
+
+// cutParameterOperator splits an expression such as "name:-word" into the
+// parameter name, the operator, one of "-", "=", "?" and "+" optionally
+// preceded by a colon, and the word after it. It reports false if the name
+// is not directly followed by one of the operators.
+func cutParameterOperator(expression string) (string, string, string, bool) {
+	nameLength := parameterNameLength(expression)
+	if nameLength == 0 {
+		return "", "", "", false
+	}
+	name, rest := expression[:nameLength], expression[nameLength:]
+	operatorLength := 1
+	if strings.HasPrefix(rest, ":") {
+		operatorLength = 2
+	}
+	if len(rest) < operatorLength || strings.IndexByte("-=?+", rest[operatorLength-1]) < 0 {
+		return "", "", "", false
+	}
+	return name, rest[:operatorLength], rest[operatorLength:], true
+}
+
+// parameterNameLength returns the length of the parameter name at the start
+// of expression: a special parameter, a run of digits or a variable name,
+// which may be followed by a subscript such as [1].
+func parameterNameLength(expression string) int {
+	switch {
+	case expression == "":
+		return 0
+	case isDigit(expression[0]):
+		length := 1
+		for length < len(expression) && isDigit(expression[length]) {
+			length++
+		}
+		return length
+	case strings.IndexByte("?$#!@*-", expression[0]) >= 0:
+		return 1
+	}
+	length := 0
+	for length < len(expression) && isValidVariableName(expression[:length+1]) {
+		length++
+	}
+	if length > 0 && strings.HasPrefix(expression[length:], "[") {
+		if end := strings.IndexByte(expression[length:], ']'); end > 0 {
+			length += end + 1
+		}
+	}
+	return length
+}
+
+// expandParameterOperator expands ${name-word} and its variants. "-"
+// substitutes word for an unset parameter, "=" also assigns it to the
+// variable, "?" fails with word as the message and "+" substitutes word only
+// for a set parameter. With a colon, an empty value counts as unset.
+func expandParameterOperator(name, operator, word string, quoting parser.Quoting, badSubstitution error) (string, error) {
+	value, ok := lookupParameter(name)
+	if !ok {
+		return "", badSubstitution
+	}
+	isSet := isParameterSet(name)
+	if strings.HasPrefix(operator, ":") {
+		isSet = isSet && value != ""
+	}
+
+	switch strings.TrimPrefix(operator, ":") {
+	case "+":
+		if !isSet {
+			return "", nil
+		}
+		return expandParameterWord(word, quoting)
+	case "=":
+		if isSet {
+			return value, nil
+		}
+		if !isValidVariableName(name) {
+			return "", fmt.Errorf("$%s: cannot assign in this way", name)
+		}
+		expanded, err := expandParameterWord(word, quoting)
+		if err != nil {
+			return "", err
+		}
+		variables.set(name, expanded)
+		return expanded, nil
+	case "?":
+		if isSet {
+			return value, nil
+		}
+		message, err := expandParameterWord(word, quoting)
+		if err != nil {
+			return "", err
+		}
+		if message == "" {
+			message = "parameter null or not set"
+		}
+		return "", fmt.Errorf("%s: %s", name, message)
+	default:
+		if isSet {
+			return value, nil
+		}
+		return expandParameterWord(word, quoting)
+	}
+}
+
+// expandParameterWord expands the word of an expansion such as ${name:-word}
+// with tilde expansion, parameter expansion and command substitution.
+// Process substitutions are not recognized in the word, so none are started.
+func expandParameterWord(text string, quoting parser.Quoting) (string, error) {
+	word := parser.ParseParameterWord(text, quoting)
+	if quoting == parser.Unquoted {
+		word = expandTilde(word)
+	}
+	expanded, err := expandSubstitutions(word, nil)
+	if err != nil {
+		return "", err
+	}
+	return expanded.String(), nil
+}
+
+// substringParameter selects the characters of value described by the
+// "offset" or "offset:length" arguments of ${name:offset:length}. A negative
+// offset counts from the end, as does a negative length for the end.
+func substringParameter(value string, arguments string, badSubstitution error) (string, error) {
+	offsetText, lengthText, hasLength := strings.Cut(arguments, ":")
+	offset, err := strconv.Atoi(strings.TrimSpace(offsetText))
+	if err != nil {
+		return "", badSubstitution
+	}
+
+	// boundaries holds the byte position of every character, and the end.
+	var boundaries []int
+	for index := 0; index < len(value); {
+		boundaries = append(boundaries, index)
+		_, size := utf8.DecodeRuneInString(value[index:])
+		index += size
+	}
+	boundaries = append(boundaries, len(value))
+	count := len(boundaries) - 1
+
+	if offset < 0 {
+		offset += count
+	}
+	if offset < 0 || offset > count {
+		return "", nil
+	}
+
+	end := count
+	if hasLength {
+		length, err := strconv.Atoi(strings.TrimSpace(lengthText))
+		if err != nil {
+			return "", badSubstitution
+		}
+		if length < 0 {
+			end = count + length
+			if end < offset {
+				return "", fmt.Errorf("%s: substring expression < 0", strings.TrimSpace(lengthText))
+			}
+		} else {
+			end = min(offset+length, count)
+		}
+	}
+	return value[boundaries[offset]:boundaries[end]], nil
+}
+
+// substituteCommand runs a command in a subshell and returns its output
+// without the trailing newlines. The output is read while the command runs,
+// so a command writing more than a pipe holds does not block.
+func substituteCommand(command string) (string, error) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		return "", fmt.Errorf("cannot make pipe for command substitution: %v", err)
+	}
+
+	commandIO := shellio.WithFile(&shellFiles, 1, writer)
+	subshell, err := startSubshell(command, captureSubshellState(), commandIO, inheritedFiles(commandIO))
+	writer.Close()
+	if err != nil {
+		reader.Close()
+		return "", fmt.Errorf("command substitution: %v", err)
+	}
+
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	substitutionStatus = exitStatusOf(subshell.Wait())
+	return strings.TrimRight(string(data), "\n"), nil
+}
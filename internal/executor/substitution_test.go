@@ -0,0 +1,125 @@
+package executor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+)
+
+// withEmptyVariables starts a test without any shell variables and restores
+// them when it ends.
+func withEmptyVariables(t *testing.T) {
+	t.Helper()
+	saved := variables.values
+	variables.values = make(map[string]string)
+	t.Cleanup(func() { variables.values = saved })
+}
+
+func TestSubstringParameter(t *testing.T) {
+	tests := []struct {
+		value     string
+		arguments string
+		want      string
+	}{
+		{"abcdef", "2", "cdef"},
+		{"abcdef", "2:3", "cde"},
+		{"abcdef", " -2", "ef"},
+		{"abcdef", "1:-2", "bcd"},
+		{"abcdef", "0:100", "abcdef"},
+		{"abcdef", "6", ""},
+		{"abcdef", "7", ""},
+		{"abcdef", "-7", ""},
+		{"abcdef", "2:0", ""},
+		{"héllo", "1:3", "éll"},
+		{"日本語", "-1", "語"},
+		{"a\xffb", "1:1", "\xff"},
+	}
+	badSubstitution := errors.New("bad substitution")
+	for _, test := range tests {
+		got, err := substringParameter(test.value, test.arguments, badSubstitution)
+		if err != nil {
+			t.Errorf("substringParameter(%q, %q) returned error: %v", test.value, test.arguments, err)
+		} else if got != test.want {
+			t.Errorf("substringParameter(%q, %q) = %q, want %q", test.value, test.arguments, got, test.want)
+		}
+	}
+}
+
+func TestSubstringParameterErrors(t *testing.T) {
+	badSubstitution := errors.New("bad substitution")
+	for _, arguments := range []string{"", "x", "1:y"} {
+		if _, err := substringParameter("abc", arguments, badSubstitution); err != badSubstitution {
+			t.Errorf("substringParameter(%q) error = %v, want bad substitution", arguments, err)
+		}
+	}
+	if _, err := substringParameter("abc", "2:-2", badSubstitution); err == nil {
+		t.Errorf("substringParameter with the end before the offset returned no error")
+	}
+}
+
+func TestExpandParameterOperators(t *testing.T) {
+	withEmptyVariables(t)
+	variables.set("full", "value")
+	variables.set("empty", "")
+	tests := []struct {
+		expression string
+		want       string
+	}{
+		{"full:-default", "value"},
+		{"empty:-default", "default"},
+		{"unset:-default", "default"},
+		{"empty-default", ""},
+		{"unset-default", "default"},
+		{"full:+alternative", "alternative"},
+		{"empty:+alternative", ""},
+		{"empty+alternative", "alternative"},
+		{"unset+alternative", ""},
+		{"full:-2", "value"},
+		{"full: -2", "ue"},
+		{"full:1:2", "al"},
+		{"unset:-$full and more", "value and more"},
+		{"unset:-'a  b'", "a  b"},
+		{"?:-x", "0"},
+	}
+	for _, test := range tests {
+		got, err := expandParameter(test.expression, parser.Unquoted)
+		if err != nil {
+			t.Errorf("${%s} returned error: %v", test.expression, err)
+		} else if got != test.want {
+			t.Errorf("${%s} = %q, want %q", test.expression, got, test.want)
+		}
+	}
+}
+
+func TestExpandParameterAssignDefault(t *testing.T) {
+	withEmptyVariables(t)
+	got, err := expandParameter("target:=assigned", parser.Unquoted)
+	if err != nil || got != "assigned" {
+		t.Fatalf("${target:=assigned} = %q, %v, want assigned", got, err)
+	}
+	if value, _ := variables.get("target"); value != "assigned" {
+		t.Errorf("target = %q after ${target:=assigned}, want assigned", value)
+	}
+	if _, err := expandParameter("1:=x", parser.Unquoted); err == nil {
+		t.Errorf("${1:=x} returned no error")
+	}
+}
+
+func TestExpandParameterErrorIfUnset(t *testing.T) {
+	withEmptyVariables(t)
+	if _, err := expandParameter("unset:?custom message", parser.Unquoted); err == nil || err.Error() != "unset: custom message" {
+		t.Errorf("${unset:?custom message} error = %v", err)
+	}
+	if _, err := expandParameter("unset?", parser.Unquoted); err == nil || err.Error() != "unset: parameter null or not set" {
+		t.Errorf("${unset?} error = %v", err)
+	}
+}
+
+func TestExpandParameterWordInDoubleQuotes(t *testing.T) {
+	withEmptyVariables(t)
+	got, err := expandParameter("unset:-'quoted'", parser.DoubleQuoted)
+	if err != nil || got != "'quoted'" {
+		t.Errorf(`"${unset:-'quoted'}" = %q, %v, want the single quotes kept`, got, err)
+	}
+}
@@ -0,0 +1,130 @@
+package executor
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/md-talim/codecrafters-shell-go/internal/parser"
+	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
+)
+
+// commandMatch is one of the ways a command name can be run.
+type commandMatch struct {
+	kind     string // "alias", "keyword", "builtin" or "file"
+	value    string // The definition of an alias or the path of a file
+	isHashed bool   // Whether the path is remembered in the hash table
+}
+
+// lookupCommand returns the ways name can be run, in the order the shell
+// tries them, so the first is the one that runs. Unless all is set, only that
+// one is returned, and a file may come from the hash table. With onlyPath
+// set, only files are looked for. The shell has no functions, so none are
+// ever found.
+func lookupCommand(name string, all bool, onlyPath bool) []commandMatch {
+	var matches []commandMatch
+	// A name containing a slash can only be the path of a file.
+	if strings.Contains(name, "/") {
+		if isExecutableFile(name) {
+			matches = append(matches, commandMatch{kind: "file", value: name})
+		}
+		return matches
+	}
+	if !onlyPath {
+		if value, ok := aliases.lookup(name); ok {
+			matches = append(matches, commandMatch{kind: "alias", value: value})
+		}
+		if parser.IsKeyword(name) {
+			matches = append(matches, commandMatch{kind: "keyword"})
+		}
+		if _, ok := builtinCommands[name]; ok {
+			matches = append(matches, commandMatch{kind: "builtin"})
+		}
+		if len(matches) > 0 && !all {
+			return matches[:1]
+		}
+	}
+
+	if all {
+		for _, path := range findAllPaths(name) {
+			matches = append(matches, commandMatch{kind: "file", value: path})
+		}
+	} else if path, ok := hashedCommands.lookup(name); ok {
+		matches = append(matches, commandMatch{kind: "file", value: path, isHashed: true})
+	} else if path, ok := findPath(name); ok {
+		matches = append(matches, commandMatch{kind: "file", value: path})
+	}
+	return matches
+}
+
+// typeCommand describes how each name would be run if used as a command.
+// With -t it prints only the kind of each, and with -p only the path of those
+// that are files. -P searches $PATH even for names that are aliases or
+// builtins, and -a reports every match instead of the first. -f, which
+// skips functions, is accepted but changes nothing.
+func typeCommand(args []string, io shellio.IO) int {
+	options, operands, err := parseBuiltinOptions("type", args, "afptP")
+	if err != nil {
+		return usageError(io, err, "type: usage: type [-afptP] name [name ...]")
+	}
+	if len(operands) == 0 {
+		return shellError(io, 1, "type: missing operand")
+	}
+
+	all, onlyKind, onlyPath, searchPath := false, false, false, false
+	for _, option := range options {
+		switch option.flag {
+		case 'a':
+			all = true
+		case 't':
+			onlyKind = true
+		case 'p':
+			onlyPath = true
+		case 'P':
+			onlyPath, searchPath = true, true
+		}
+	}
+
+	status := 0
+	for _, name := range operands {
+		matches := lookupCommand(name, all, searchPath)
+		if len(matches) == 0 {
+			// -t and -p only print what they find.
+			if !onlyKind && !onlyPath {
+				shellError(io, 1, "type: %s: not found", name)
+			}
+			status = 1
+			continue
+		}
+
+		for _, match := range matches {
+			switch {
+			case onlyKind:
+				fmt.Fprintln(io.OutputFile(), match.kind)
+			case onlyPath:
+				if match.kind == "file" {
+					fmt.Fprintln(io.OutputFile(), match.value)
+				}
+			default:
+				fmt.Fprintln(io.OutputFile(), describeCommand(name, match))
+			}
+		}
+	}
+	return status
+}
+
+// describeCommand returns the line type prints for a match.
+func describeCommand(name string, match commandMatch) string {
+	switch match.kind {
+	case "alias":
+		return fmt.Sprintf("%s is aliased to `%s'", name, match.value)
+	case "keyword":
+		return fmt.Sprintf("%s is a shell keyword", name)
+	case "builtin":
+		return fmt.Sprintf("%s is a shell builtin", name)
+	default:
+		if match.isHashed {
+			return fmt.Sprintf("%s is hashed (%s)", name, match.value)
+		}
+		return fmt.Sprintf("%s is %s", name, match.value)
+	}
+}
@@ -0,0 +1,118 @@
+package executor
+
+import "os"
+
+// ShellVariables holds variables assigned by the shell. Variables that are
+// already part of the environment are updated in place so they stay exported.
+type ShellVariables struct {
+	values map[string]string
+	arrays map[string][]string
+}
+
+// get returns the value of a variable. For arrays this is the first element.
+func (v *ShellVariables) get(name string) (string, bool) {
+	if array, ok := v.arrays[name]; ok {
+		if len(array) == 0 {
+			return "", true
+		}
+		return array[0], true
+	}
+	if value, ok := v.values[name]; ok {
+		return value, true
+	}
+	return os.LookupEnv(name)
+}
+
+func (v *ShellVariables) set(name string, value string) {
+	if array, ok := v.arrays[name]; ok && len(array) > 0 {
+		array[0] = value
+		return
+	}
+	delete(v.arrays, name)
+	if _, isExported := os.LookupEnv(name); isExported {
+		os.Setenv(name, value)
+		return
+	}
+	if v.values == nil {
+		v.values = make(map[string]string)
+	}
+	v.values[name] = value
+}
+
+// export assigns a variable and places it in the environment of commands
+// started by the shell.
+func (v *ShellVariables) export(name string, value string) {
+	delete(v.values, name)
+	delete(v.arrays, name)
+	os.Setenv(name, value)
+}
+
+// savedVariable is the state of a variable before a temporary assignment.
+type savedVariable struct {
+	name          string
+	value         string
+	isSet         bool
+	array         []string
+	isArray       bool
+	exportedValue string
+	isExported    bool
+}
+
+func (v *ShellVariables) save(name string) savedVariable {
+	saved := savedVariable{name: name}
+	saved.value, saved.isSet = v.values[name]
+	saved.array, saved.isArray = v.arrays[name]
+	saved.exportedValue, saved.isExported = os.LookupEnv(name)
+	return saved
+}
+
+func (v *ShellVariables) restore(saved savedVariable) {
+	delete(v.values, saved.name)
+	delete(v.arrays, saved.name)
+	os.Unsetenv(saved.name)
+	if saved.isExported {
+		os.Setenv(saved.name, saved.exportedValue)
+	}
+	if saved.isSet {
+		v.values[saved.name] = saved.value
+	}
+	if saved.isArray {
+		v.arrays[saved.name] = saved.array
+	}
+}
+
+// unset removes a variable from the shell and from the environment.
+func (v *ShellVariables) unset(name string) {
+	delete(v.values, name)
+	delete(v.arrays, name)
+	os.Unsetenv(name)
+}
+
+func (v *ShellVariables) getArray(name string) ([]string, bool) {
+	array, ok := v.arrays[name]
+	return array, ok
+}
+
+func (v *ShellVariables) setArray(name string, values []string) {
+	delete(v.values, name)
+	if v.arrays == nil {
+		v.arrays = make(map[string][]string)
+	}
+	v.arrays[name] = values
+}
+
+// isValidVariableName reports whether name is a valid shell identifier.
+func isValidVariableName(name string) bool {
+	if len(name) == 0 {
+		return false
+	}
+	for i := 0; i < len(name); i++ {
+		character := name[i]
+		isLetter := (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || character == '_'
+		isDigit := character >= '0' && character <= '9'
+		if !isLetter && !(isDigit && i > 0) {
+			return false
+		}
+	}
+	return true
+}
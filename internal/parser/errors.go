@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SyntaxError is an error in the input that prevents it from being run. It
+// records the offending token and where it was found, so that callers can
+// point at it.
+type SyntaxError struct {
+	Message string
+	Token   string
+	// Line and Column are 1-based. Columns count characters, not bytes.
+	Line   int
+	Column int
+	// Source is the line of input the error was found on.
+	Source string
+	// IsIncomplete is set if the input ended in the middle of a command, in
+	// which case reading more input may complete it.
+	IsIncomplete bool
+}
+
+func (e *SyntaxError) Error() string {
+	return e.Message
+}
+
+// Snippet returns the line of input the error was found on, followed by a
+// line with a caret under the offending token.
+func (e *SyntaxError) Snippet() string {
+	var caret strings.Builder
+	column := 1
+	for _, character := range e.Source {
+		if column >= e.Column {
+			break
+		}
+		// Tabs are kept so that the caret lines up with the source.
+		if character == TAB {
+			caret.WriteRune(TAB)
+		} else {
+			caret.WriteByte(SPACE)
+		}
+		column++
+	}
+	return fmt.Sprintf("%s\n%s^\n", e.Source, caret.String())
+}
+
+// IsIncomplete reports whether err is a syntax error caused by the input
+// ending in the middle of a command.
+func IsIncomplete(err error) bool {
+	var syntaxError *SyntaxError
+	return errors.As(err, &syntaxError) && syntaxError.IsIncomplete
+}
+
+// newSyntaxError returns a syntax error located at offset in the input.
+func (p *Parser) newSyntaxError(message string, token string, offset int) *SyntaxError {
+	offset = min(offset, len(p.Input))
+	lineStart := strings.LastIndexByte(p.Input[:offset], NEWLINE) + 1
+	lineEnd := strings.IndexByte(p.Input[offset:], NEWLINE)
+	if lineEnd < 0 {
+		lineEnd = len(p.Input)
+	} else {
+		lineEnd += offset
+	}
+
+	return &SyntaxError{
+		Message: message,
+		Token:   token,
+		Line:    strings.Count(p.Input[:lineStart], string(NEWLINE)) + 1,
+		Column:  utf8.RuneCountInString(p.Input[lineStart:offset]) + 1,
+		Source:  strings.TrimSuffix(p.Input[lineStart:lineEnd], string(RETURN)),
+	}
+}
+
+// unexpectedTokenError reports an operator found where it is not allowed,
+// such as a pipe with no command before it.
+func (p *Parser) unexpectedTokenError(token string) *SyntaxError {
+	if token == string(NEWLINE) {
+		token = "newline"
+	}
+	message := fmt.Sprintf("syntax error near unexpected token `%s'", token)
+	return p.newSyntaxError(message, token, p.tokenStart)
+}
+
+// unexpectedEndError reports input that ends where more is required, such as
+// after a pipe.
+func (p *Parser) unexpectedEndError(offset int) *SyntaxError {
+	err := p.newSyntaxError("syntax error: unexpected end of file", "", offset)
+	err.IsIncomplete = true
+	return err
+}
+
+// markIncomplete records that the input ended while looking for the
+// character that closes the quote or substitution opened at opening.
+func (p *Parser) markIncomplete(closing byte, opening int) {
+	if p.incomplete != nil {
+		return
+	}
+	message := fmt.Sprintf("unexpected EOF while looking for matching `%c'", closing)
+	p.incomplete = p.newSyntaxError(message, string(closing), opening)
+	p.incomplete.IsIncomplete = true
+}
@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// EscapeMode selects which backslash escapes DecodeEscape understands, since
+// $'...', printf and echo -e each accept a slightly different set.
+type EscapeMode int
+
+const (
+	EscapeModeANSIC    EscapeMode = iota // $'...' string
+	EscapeModeFormat                     // printf format string
+	EscapeModeArgument                   // printf %b argument
+	EscapeModeEcho                       // echo -e argument
+)
+
+// DecodeEscape decodes the escape sequence at the start of sequence, which
+// follows a backslash. It returns the decoded text, the number of bytes
+// consumed and whether a \c escape asked to stop producing output, which
+// only printf %b and echo -e do. Unknown escapes are kept as they are,
+// backslash included. The error reports a \x, \u or \U without digits.
+func DecodeEscape(sequence string, mode EscapeMode) (string, int, bool, error) {
+	if len(sequence) == 0 {
+		return `\`, 0, false, nil
+	}
+
+	switch character := sequence[0]; character {
+	case 'a':
+		return "\a", 1, false, nil
+	case 'b':
+		return "\b", 1, false, nil
+	case 'e', 'E':
+		return "\x1b", 1, false, nil
+	case 'f':
+		return "\f", 1, false, nil
+	case 'n':
+		return "\n", 1, false, nil
+	case 'r':
+		return "\r", 1, false, nil
+	case 't':
+		return "\t", 1, false, nil
+	case 'v':
+		return "\v", 1, false, nil
+	case BACKSLASH, DOUBLE:
+		return string(character), 1, false, nil
+	case SINGLE, '?':
+		if mode == EscapeModeANSIC || mode == EscapeModeFormat {
+			return string(character), 1, false, nil
+		}
+	case 'c':
+		switch {
+		case mode == EscapeModeArgument || mode == EscapeModeEcho:
+			return "", 1, true, nil
+		case mode == EscapeModeANSIC && len(sequence) > 1:
+			// \cX is the control character of X, and \c? is DEL.
+			if sequence[1] == '?' {
+				return "\x7f", 2, false, nil
+			}
+			return string([]byte{sequence[1] & 0x1f}), 2, false, nil
+		}
+	case 'x':
+		value, digits := parseDigits(sequence[1:], 16, 2)
+		if digits == 0 {
+			return `\x`, 1, false, fmt.Errorf("missing hex digit for \\x")
+		}
+		return string([]byte{byte(value)}), 1 + digits, false, nil
+	case 'u', 'U':
+		maxDigits := 4
+		if character == 'U' {
+			maxDigits = 8
+		}
+		value, digits := parseDigits(sequence[1:], 16, maxDigits)
+		if digits == 0 {
+			return `\` + string(character), 1, false, fmt.Errorf("missing unicode digit for \\%c", character)
+		}
+		return string(rune(value)), 1 + digits, false, nil
+	case '0':
+		// printf %b and echo -e take \0nnn; elsewhere the leading zero
+		// counts towards the three octal digits.
+		if mode == EscapeModeArgument || mode == EscapeModeEcho {
+			value, digits := parseDigits(sequence[1:], 8, 3)
+			return string([]byte{byte(value)}), 1 + digits, false, nil
+		}
+		fallthrough
+	case '1', '2', '3', '4', '5', '6', '7':
+		if mode != EscapeModeEcho {
+			value, digits := parseDigits(sequence, 8, 3)
+			return string([]byte{byte(value)}), digits, false, nil
+		}
+	}
+
+	return `\` + sequence[:1], 1, false, nil
+}
+
+// parseDigits parses at most maxDigits leading digits of text in the given
+// base and returns the value along with the number of digits used.
+func parseDigits(text string, base int, maxDigits int) (int64, int) {
+	var value int64
+	digits := 0
+	for digits < len(text) && digits < maxDigits {
+		digit, err := strconv.ParseInt(text[digits:digits+1], base, 64)
+		if err != nil {
+			break
+		}
+		value = value*int64(base) + digit
+		digits++
+	}
+	return value, digits
+}
@@ -0,0 +1,82 @@
+package parser
+
+import "testing"
+
+func TestDecodeEscape(t *testing.T) {
+	tests := []struct {
+		sequence     string
+		mode         EscapeMode
+		wantText     string
+		wantConsumed int
+		wantStop     bool
+	}{
+		{"n", EscapeModeANSIC, "\n", 1, false},
+		{"e[0m", EscapeModeANSIC, "\x1b", 1, false},
+		{`\`, EscapeModeEcho, `\`, 1, false},
+		{"", EscapeModeANSIC, `\`, 0, false},
+		{"x41z", EscapeModeANSIC, "A", 3, false},
+		{"x4", EscapeModeFormat, "\x04", 2, false},
+		{"u00e9", EscapeModeANSIC, "é", 5, false},
+		{"U0001F600", EscapeModeArgument, "😀", 9, false},
+		{"101", EscapeModeANSIC, "A", 3, false},
+		{"0101", EscapeModeFormat, "\b", 3, false},
+		{"0101", EscapeModeArgument, "A", 4, false},
+		{"0101", EscapeModeEcho, "A", 4, false},
+		{"101", EscapeModeEcho, `\1`, 1, false},
+		{"'", EscapeModeANSIC, "'", 1, false},
+		{"'", EscapeModeEcho, `\'`, 1, false},
+		{"cA", EscapeModeANSIC, "\x01", 2, false},
+		{"c?", EscapeModeANSIC, "\x7f", 2, false},
+		{"c", EscapeModeANSIC, `\c`, 1, false},
+		{"c", EscapeModeFormat, `\c`, 1, false},
+		{"cX", EscapeModeArgument, "", 1, true},
+		{"c", EscapeModeEcho, "", 1, true},
+		{"q", EscapeModeANSIC, `\q`, 1, false},
+	}
+	for _, test := range tests {
+		text, consumed, stop, err := DecodeEscape(test.sequence, test.mode)
+		if err != nil {
+			t.Errorf("DecodeEscape(%q, %d) returned error: %v", test.sequence, test.mode, err)
+		}
+		if text != test.wantText || consumed != test.wantConsumed || stop != test.wantStop {
+			t.Errorf("DecodeEscape(%q, %d) = %q, %d, %v, want %q, %d, %v",
+				test.sequence, test.mode, text, consumed, stop, test.wantText, test.wantConsumed, test.wantStop)
+		}
+	}
+}
+
+func TestDecodeEscapeWithoutDigits(t *testing.T) {
+	for _, sequence := range []string{"x", "xg", "u", "U"} {
+		text, consumed, _, err := DecodeEscape(sequence, EscapeModeANSIC)
+		if err == nil {
+			t.Errorf("DecodeEscape(%q) returned no error", sequence)
+		}
+		if want := `\` + sequence[:1]; text != want || consumed != 1 {
+			t.Errorf("DecodeEscape(%q) = %q, %d, want %q, 1", sequence, text, consumed, want)
+		}
+	}
+}
+
+func TestParseANSICQuoted(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`$'a\tb'`, "a\tb"},
+		{`$'it\'s'`, "it's"},
+		{`$'\x41\101'`, "AA"},
+		{`$'é'`, "é"},
+		{`$'a\\b'`, `a\b`},
+		{`x$'\n'y`, "x\ny"},
+	}
+	for _, test := range tests {
+		commands := parseCommands(t, test.input)
+		if len(commands) != 1 || len(commands[0].Args) != 1 {
+			t.Errorf("Parse(%q) = %v, want a single word", test.input, commands)
+			continue
+		}
+		if got := commands[0].Args[0].String(); got != test.want {
+			t.Errorf("Parse(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
@@ -1,140 +1,528 @@
 package parser
 
-import (
-	"fmt"
-	"os"
-	"strings"
-
-	"github.com/md-talim/codecrafters-shell-go/internal/shellio"
-)
+import "strings"
 
 const (
 	END       = '\x00' // Null character
 	SPACE     = ' '    // Space character
+	TAB       = '\t'   // Tab character
+	NEWLINE   = '\n'   // Newline character
+	RETURN    = '\r'   // Carriage return
+	SEMICOLON = ';'    // Command terminator
+	PIPE      = '|'    // Pipe operator
+	AMPERSAND = '&'    // Starts the "&&" operator
+	COMMENT   = '#'    // Starts a comment
 	SINGLE    = '\''   // Single quote
 	DOUBLE    = '"'    // Double quote
 	BACKSLASH = '\\'   // Backslash
+	DOLLAR    = '$'    // Dollar sign
+	BACKQUOTE = '`'    // Backquote
 )
 
+// AliasResolver returns the replacement text of an alias, if name is one.
+type AliasResolver func(name string) (string, bool)
+
 type Parser struct {
-	Input string
-	Index int
+	Input        string
+	Index        int
+	ResolveAlias AliasResolver
+	// ExtendedPatterns keeps operators inside the parentheses of an extended
+	// pattern such as @(a|b) in the word, for when the extglob option is on.
+	ExtendedPatterns bool
+
+	activeAliases []activeAlias
+	// aliasChainEnd is the end of the replacement text of an alias ending in
+	// a blank, whose following word is also checked for aliases; -1 if none.
+	aliasChainEnd int
+	// incomplete is set when the input ended in the middle of a word, such
+	// as inside quotes.
+	incomplete *SyntaxError
+	// tokenStart is the position of the first character of the last word or
+	// operator read, which syntax errors point at.
+	tokenStart int
+}
+
+// activeAlias is an alias whose replacement text is still being parsed. It
+// is not expanded again until the parser moves past End, which prevents
+// recursive expansion.
+type activeAlias struct {
+	Name string
+	End  int
 }
 
 func NewParser(input string) Parser {
 	return Parser{
-		Input: input,
-		Index: -1,
+		Input:         input,
+		Index:         -1,
+		aliasChainEnd: -1,
 	}
 }
 
-func (p *Parser) Parse() ([][]string, shellio.RedirectionConfig) {
+// Command is a single command of a pipeline along with its redirections.
+// Its words are kept as written so the executor can expand them. A command
+// started by the coproc keyword has no words of its own; Coprocess holds the
+// commands it runs instead.
+type Command struct {
+	Args         []Word
+	Redirections []Redirection
+	Coprocess    *Coprocess
+}
+
+func (c Command) isEmpty() bool {
+	return len(c.Args) == 0 && len(c.Redirections) == 0 && c.Coprocess == nil
+}
+
+// Coprocess is a list of commands run asynchronously by the coproc keyword,
+// either "coproc [NAME] { list; }" or "coproc command", with pipes connected
+// to its input and output.
+type Coprocess struct {
+	Name string
+	Body []AndOrList
+	// BodyText is the body as written, after alias expansion, for running
+	// it on its own.
+	BodyText string
+	// Text is the coproc command as written, for listing it as a job.
+	Text string
+
+	start     int // The position of the coproc keyword
+	bodyStart int // The position after the keyword, or after '{'
+}
+
+// defaultCoprocessName is the name of a coprocess that is not given one.
+const defaultCoprocessName = "COPROC"
+
+// Pipeline is a sequence of commands connected by pipes.
+type Pipeline []Command
+
+// AndOrList is a sequence of pipelines joined by "&&" or "||". A pipeline
+// after "&&" runs only if the exit status so far is zero, and one after "||"
+// only if it is not.
+type AndOrList struct {
+	Pipelines []Pipeline
+	// Operators holds the operator before each pipeline after the first.
+	Operators []string
+}
+
+// Redirection is a redirection operator along with its unexpanded target.
+type Redirection struct {
+	Operator string
+	Target   Word
+}
+
+// Parse splits the input into and-or lists, which are terminated by ';' or a
+// newline. Blank lines are skipped and a newline after a pipe, "&&" or "||"
+// continues the list. A syntax error is returned if a list is malformed.
+func (p *Parser) Parse() ([]AndOrList, error) {
+	return p.parseList("")
+}
+
+// parseList parses and-or lists until the end of the input or, if closing is
+// set, until a word equal to closing where a pipeline could start, such as
+// the '}' that ends the body of a coprocess.
+func (p *Parser) parseList(closing string) ([]AndOrList, error) {
 	var (
-		allCommands        [][]string
-		currentCommandArgs []string
-		redirection        shellio.RedirectionConfig
+		lists             []AndOrList
+		currentList       AndOrList
+		currentPipeline   Pipeline
+		currentCommand    Command
+		isCommandPosition = true
+		// simpleCoprocess is set while reading the pipeline of a
+		// "coproc command", which becomes its body once it ends.
+		simpleCoprocess *Coprocess
 	)
 
+	endPipeline := func(end int) {
+		if simpleCoprocess != nil {
+			simpleCoprocess.Body = []AndOrList{{Pipelines: []Pipeline{currentPipeline}}}
+			simpleCoprocess.BodyText = strings.TrimSpace(p.Input[simpleCoprocess.bodyStart:end])
+			simpleCoprocess.Text = strings.TrimSpace(p.Input[simpleCoprocess.start:end])
+			currentPipeline = Pipeline{{Coprocess: simpleCoprocess}}
+			simpleCoprocess = nil
+		}
+		currentList.Pipelines = append(currentList.Pipelines, currentPipeline)
+		currentPipeline = nil
+	}
+	endList := func() {
+		lists = append(lists, currentList)
+		currentList = AndOrList{}
+	}
+	// isAfterAndOr reports whether the list ends in "&&" or "||", which
+	// must be followed by another pipeline.
+	isAfterAndOr := func() bool {
+		return len(currentList.Operators) > 0 && len(currentList.Operators) == len(currentList.Pipelines)
+	}
+
 	for {
+		if isCommandPosition || p.isAfterAliasChain() {
+			p.aliasChainEnd = -1
+			p.expandAliases()
+		}
+
 		argument := p.nextArgument()
+		if p.incomplete != nil {
+			return nil, p.incomplete
+		}
 		if argument == nil {
-			if len(currentCommandArgs) > 0 {
-				allCommands = append(allCommands, currentCommandArgs)
+			if !currentCommand.isEmpty() {
+				currentPipeline = append(currentPipeline, currentCommand)
+			} else if len(currentPipeline) > 0 || simpleCoprocess != nil || isAfterAndOr() {
+				return nil, p.unexpectedEndError(len(p.Input))
+			}
+			if closing != "" {
+				return nil, p.unexpectedEndError(len(p.Input))
+			}
+			if len(currentPipeline) > 0 {
+				endPipeline(len(p.Input))
+				endList()
 			}
 			break
 		}
 
-		token := *argument
+		token, isUnquoted := argument.UnquotedText()
+		isPipelineStart := currentCommand.isEmpty() && len(currentPipeline) == 0
 
-		if token == "|" {
-			if len(currentCommandArgs) == 0 {
-				return nil, shellio.RedirectionConfig{}
+		if isUnquoted && closing != "" && token == closing && isPipelineStart && simpleCoprocess == nil {
+			if isAfterAndOr() {
+				return nil, p.unexpectedTokenError(token)
 			}
-			allCommands = append(allCommands, currentCommandArgs)
-			currentCommandArgs = []string{} // Reset for the next command
-		} else if isRedirectionOperator(*argument) {
-			// Redirection applies to the ouput of the last command in the pipeline.
-			// Expect this at the end of the command line.
-			if len(allCommands) == 0 && len(currentCommandArgs) == 0 {
-				return nil, shellio.RedirectionConfig{}
+			return lists, nil
+		} else if isUnquoted && token == "coproc" && isPipelineStart && simpleCoprocess == nil {
+			coprocess, err := p.parseCoprocess()
+			if err != nil {
+				return nil, err
 			}
-			// Add pending arguments for the current (which will be the last) command.
-			if len(currentCommandArgs) > 0 {
-				allCommands = append(allCommands, currentCommandArgs)
+			if coprocess.Body == nil {
+				simpleCoprocess = coprocess
+			} else {
+				currentCommand.Coprocess = coprocess
+				isCommandPosition = false
 			}
-			// If currentCommandArgs is empt here, but allCommands is not, it implies "cmd1 | > out".
-			// This is a syntax error (missing command after pipe before redirection).
-			if len(allCommands) > 0 && len(allCommands[len(allCommands)-1]) == 0 {
-				return nil, shellio.RedirectionConfig{}
+		} else if isUnquoted && token == "|" {
+			if currentCommand.isEmpty() || currentCommand.Coprocess != nil {
+				return nil, p.unexpectedTokenError(token)
 			}
-
+			currentPipeline = append(currentPipeline, currentCommand)
+			currentCommand = Command{} // Reset for the next command
+			isCommandPosition = true
+		} else if isUnquoted && isAndOrOperator(token) {
+			if currentCommand.isEmpty() {
+				return nil, p.unexpectedTokenError(token)
+			}
+			currentPipeline = append(currentPipeline, currentCommand)
+			endPipeline(p.tokenStart)
+			currentList.Operators = append(currentList.Operators, token)
+			currentCommand = Command{}
+			isCommandPosition = true
+		} else if isUnquoted && isTerminator(token) {
+			if currentCommand.isEmpty() {
+				// Blank lines, and a newline after a pipe, "&&" or "||",
+				// are skipped.
+				if token == string(NEWLINE) && simpleCoprocess == nil {
+					continue
+				}
+				return nil, p.unexpectedTokenError(token)
+			}
+			currentPipeline = append(currentPipeline, currentCommand)
+			endPipeline(p.tokenStart)
+			endList()
+			currentCommand = Command{}
+			isCommandPosition = true
+		} else if isUnquoted && isRedirectionOperator(token) {
 			fileName := p.nextArgument()
+			if p.incomplete != nil {
+				return nil, p.incomplete
+			}
 			if fileName == nil {
-				fmt.Fprintln(os.Stderr, "Error: Missing file name for redirection")
-				return nil, shellio.RedirectionConfig{}
+				p.tokenStart = len(p.Input)
+				return nil, p.unexpectedTokenError(string(NEWLINE))
 			}
-			redirection = shellio.NewRedirectionConfig(token, *fileName)
-			break
+			if fileNameToken, isUnquoted := fileName.UnquotedText(); isUnquoted && isOperator(fileNameToken) {
+				return nil, p.unexpectedTokenError(fileNameToken)
+			}
+			redirection := Redirection{Operator: token, Target: *fileName}
+			currentCommand.Redirections = append(currentCommand.Redirections, redirection)
 		} else {
-			currentCommandArgs = append(currentCommandArgs, token)
+			if currentCommand.Coprocess != nil {
+				return nil, p.unexpectedTokenError(token)
+			}
+			currentCommand.Args = append(currentCommand.Args, *argument)
+			isCommandPosition = false
+		}
+	}
+	return lists, nil
+}
+
+// parseCoprocess parses what follows the coproc keyword. If it is a body in
+// braces, optionally preceded by a name, the body is parsed. Otherwise the
+// rest of the pipeline is the body, which the caller collects.
+func (p *Parser) parseCoprocess() (*Coprocess, error) {
+	coprocess := &Coprocess{Name: defaultCoprocessName, start: p.tokenStart}
+	afterKeyword := p.Index
+	coprocess.bodyStart = min(afterKeyword+1, len(p.Input))
+
+	name := p.nextArgument()
+	if name == nil || p.incomplete != nil {
+		p.Index, p.incomplete = afterKeyword, nil
+		return coprocess, nil
+	}
+	nameToken, isUnquoted := name.UnquotedText()
+	if isUnquoted && nameToken != "{" && !isOperator(nameToken) {
+		if brace := p.nextArgument(); brace != nil && p.incomplete == nil {
+			if braceToken, isUnquoted := brace.UnquotedText(); isUnquoted && braceToken == "{" {
+				coprocess.Name = nameToken
+				return p.parseCoprocessBody(coprocess)
+			}
 		}
+		p.incomplete = nil
+	} else if isUnquoted && nameToken == "{" {
+		return p.parseCoprocessBody(coprocess)
+	}
+
+	p.Index = afterKeyword
+	return coprocess, nil
+}
+
+// parseCoprocessBody parses the commands of a coprocess up to the closing
+// brace, which must not come before the first command.
+func (p *Parser) parseCoprocessBody(coprocess *Coprocess) (*Coprocess, error) {
+	coprocess.bodyStart = min(p.Index+1, len(p.Input))
+	body, err := p.parseList("}")
+	if err != nil {
+		return nil, err
+	}
+	if len(body) == 0 {
+		return nil, p.unexpectedTokenError("}")
 	}
-	return allCommands, redirection
+	coprocess.Body = body
+	coprocess.BodyText = strings.TrimSpace(p.Input[coprocess.bodyStart:p.tokenStart])
+	coprocess.Text = strings.TrimSpace(p.Input[coprocess.start:min(p.Index+1, len(p.Input))])
+	return coprocess, nil
 }
 
-func (p *Parser) nextArgument() *string {
-	builder := strings.Builder{}
+// expandAliases replaces the word at the current position with the text of
+// the alias it names, as long as the word is unquoted and the alias is not
+// already being expanded. The replacement is spliced into the input so it is
+// parsed like any other text, and its first word is checked again. If the
+// replacement ends with a blank, the word that follows it is also checked.
+func (p *Parser) expandAliases() {
+	if p.ResolveAlias == nil {
+		return
+	}
+
+	for {
+		p.skipSpaces()
+		start := min(p.Index+1, len(p.Input))
+		end := start
+		for end < len(p.Input) && !isBlank(p.Input[end]) && !isOperatorCharacter(p.Input[end]) {
+			end++
+		}
+
+		word := p.Input[start:end]
+		if len(word) == 0 || strings.ContainsAny(word, "'\"\\") {
+			return
+		}
+
+		p.dropFinishedAliases(start)
+		if p.isAliasActive(word) {
+			return
+		}
+		value, ok := p.ResolveAlias(word)
+		if !ok {
+			return
+		}
+
+		delta := len(value) - len(word)
+		for index := range p.activeAliases {
+			p.activeAliases[index].End += delta
+		}
+		if p.aliasChainEnd > start {
+			p.aliasChainEnd += delta
+		}
+		p.activeAliases = append(p.activeAliases, activeAlias{Name: word, End: start + len(value)})
+		p.Input = p.Input[:start] + value + p.Input[end:]
+		if strings.HasSuffix(value, " ") || strings.HasSuffix(value, "\t") {
+			p.aliasChainEnd = start + len(value)
+		}
+	}
+}
+
+// isAfterAliasChain reports whether the next word directly follows the text
+// of an alias that ended with a blank.
+func (p *Parser) isAfterAliasChain() bool {
+	if p.aliasChainEnd < 0 {
+		return false
+	}
+	p.skipSpaces()
+	return p.Index+1 >= p.aliasChainEnd
+}
+
+// dropFinishedAliases forgets the aliases whose replacement text lies
+// entirely before position.
+func (p *Parser) dropFinishedAliases(position int) {
+	active := p.activeAliases[:0]
+	for _, alias := range p.activeAliases {
+		if alias.End > position {
+			active = append(active, alias)
+		}
+	}
+	p.activeAliases = active
+}
+
+func (p *Parser) isAliasActive(name string) bool {
+	for _, alias := range p.activeAliases {
+		if alias.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Parser) skipSpaces() {
+	for p.Index+1 < len(p.Input) && isBlank(p.Input[p.Index+1]) {
+		p.Index++
+	}
+}
+
+// nextArgument returns the next word of the input, or nil at the end of it.
+// The operators '|', "||", "&&", ';' and newline end a word and are returned
+// as words of their own, except inside the parentheses of an extended
+// pattern such as @(a|b) when ExtendedPatterns is set.
+func (p *Parser) nextArgument() *Word {
+	var word Word
+	parenthesisDepth := 0
 
 	for {
 		character := p.next()
 		if character == END {
 			break
 		}
+		if len(word) == 0 && !isBlank(character) {
+			p.tokenStart = p.Index
+		}
 
 		switch character {
-		case SPACE:
-			if builder.Len() > 0 {
-				result := builder.String()
-				return &result
+		case SPACE, TAB:
+			if len(word) > 0 {
+				return &word
 			}
-		case BACKSLASH:
-			p.handleBackshalsh(&builder, false)
-		case SINGLE:
-			for {
-				character = p.next()
-				if character == END || character == SINGLE {
-					break
-				}
-				builder.WriteByte(character)
+		case PIPE, SEMICOLON, NEWLINE:
+			if character != NEWLINE && parenthesisDepth > 0 {
+				word.writeByte(character, Unquoted)
+				continue
 			}
-		case DOUBLE:
-			for {
-				character = p.next()
-				if character == END || character == DOUBLE {
-					break
-				}
-				if character == BACKSLASH {
-					p.handleBackshalsh(&builder, true)
-				} else {
-					builder.WriteByte(character)
+			if len(word) > 0 {
+				p.Index-- // The operator is returned by the next call.
+				return &word
+			}
+			if character == PIPE && p.peek() == PIPE {
+				p.next()
+				return &Word{{Text: "||", Quoting: Unquoted}}
+			}
+			return &Word{{Text: string(character), Quoting: Unquoted}}
+		case AMPERSAND:
+			if p.peek() != AMPERSAND || parenthesisDepth > 0 {
+				word.writeByte(character, Unquoted)
+				continue
+			}
+			if len(word) > 0 {
+				p.Index-- // The operator is returned by the next call.
+				return &word
+			}
+			p.next()
+			return &Word{{Text: "&&", Quoting: Unquoted}}
+		case RETURN:
+			// A carriage return ending a line is dropped, so that files with
+			// CRLF line endings are read like any other.
+			if p.peek() != NEWLINE {
+				word.writeByte(character, Unquoted)
+			}
+		case '(', ')':
+			switch {
+			case !p.ExtendedPatterns:
+			case character == '(':
+				parenthesisDepth++
+			case parenthesisDepth > 0:
+				parenthesisDepth--
+			}
+			word.writeByte(character, Unquoted)
+		case '<', '>':
+			if p.peek() == '(' {
+				kind := InputProcessSubstitution
+				if character == '>' {
+					kind = OutputProcessSubstitution
 				}
+				p.next()
+				word.addExpansion(kind, p.readCommandSubstitution(), Unquoted)
+				continue
 			}
+			if parenthesisDepth > 0 {
+				word.writeByte(character, Unquoted)
+				continue
+			}
+			// A redirection operator ends the word before it, unless that
+			// word is the number of the descriptor it applies to.
+			descriptor, isUnquoted := word.UnquotedText()
+			if len(word) > 0 && (!isUnquoted || !isDescriptorNumber(descriptor)) {
+				p.Index-- // The operator is returned by the next call.
+				return &word
+			}
+			return p.readRedirectionOperator(descriptor, character)
+		case COMMENT:
+			if len(word) > 0 {
+				word.writeByte(character, Unquoted)
+				continue
+			}
+			// A comment runs until the end of the line.
+			for p.peek() != END && p.peek() != NEWLINE {
+				p.next()
+			}
+		case BACKSLASH:
+			p.handleBackshalsh(&word, false)
+		case DOLLAR:
+			p.handleDollar(&word, Unquoted)
+		case BACKQUOTE:
+			word.addExpansion(CommandSubstitution, p.readBackquoted(), Unquoted)
+		case SINGLE:
+			p.readSingleQuoted(&word)
+		case DOUBLE:
+			p.readDoubleQuoted(&word)
 		default:
-			builder.WriteByte(character)
+			word.writeByte(character, Unquoted)
 		}
 	}
 
-	if builder.Len() > 0 {
-		result := builder.String()
-		return &result
+	if len(word) > 0 {
+		return &word
 	}
 
 	return nil
 }
 
-func (p *Parser) handleBackshalsh(builder *strings.Builder, inQuotes bool) {
+// readRedirectionOperator returns the redirection operator starting with
+// character: '<', '>', ">>", "<&" or ">&", prefixed with a descriptor
+// number if one was given.
+func (p *Parser) readRedirectionOperator(descriptor string, character byte) *Word {
+	operator := descriptor + string(character)
+	if character == '>' && p.peek() == '>' {
+		operator += string(p.next())
+	} else if p.peek() == '&' {
+		operator += string(p.next())
+	}
+	return &Word{{Text: operator, Quoting: Unquoted}}
+}
+
+// handleBackshalsh adds the character escaped by a backslash to the word.
+// Outside of quotes it is taken literally, as if it were single-quoted. A
+// backslash-newline pair continues the line and is removed entirely.
+func (p *Parser) handleBackshalsh(word *Word, inQuotes bool) {
 	character := p.next()
 	if character == END {
+		if p.incomplete == nil {
+			p.incomplete = p.unexpectedEndError(p.Index - 1)
+		}
+		return
+	}
+	if character == NEWLINE {
 		return
 	}
 	if inQuotes {
@@ -142,10 +530,217 @@ func (p *Parser) handleBackshalsh(builder *strings.Builder, inQuotes bool) {
 		if mapped != END {
 			character = mapped
 		} else {
-			builder.WriteByte(BACKSLASH)
+			word.writeByte(BACKSLASH, DoubleQuoted)
+		}
+		word.writeByte(character, DoubleQuoted)
+		return
+	}
+	word.writeByte(character, SingleQuoted)
+}
+
+// readSingleQuoted adds the text up to the closing single quote to the word.
+func (p *Parser) readSingleQuoted(word *Word) {
+	opening := p.Index
+	word.startPart(SingleQuoted)
+	for {
+		character := p.next()
+		if character == END {
+			p.markIncomplete(SINGLE, opening)
+			break
+		}
+		if character == SINGLE {
+			break
+		}
+		word.writeByte(character, SingleQuoted)
+	}
+}
+
+// readDoubleQuoted adds the text up to the closing double quote to the word.
+// Backslashes, parameter expansions and command substitutions keep their
+// meaning inside double quotes.
+func (p *Parser) readDoubleQuoted(word *Word) {
+	opening := p.Index
+	word.startPart(DoubleQuoted)
+	for {
+		character := p.next()
+		if character == END {
+			p.markIncomplete(DOUBLE, opening)
+			break
+		}
+		if character == DOUBLE {
+			break
+		}
+		if character == BACKSLASH {
+			p.handleBackshalsh(word, true)
+		} else if character == DOLLAR {
+			p.handleDollar(word, DoubleQuoted)
+		} else if character == BACKQUOTE {
+			word.addExpansion(CommandSubstitution, p.readBackquoted(), DoubleQuoted)
+		} else {
+			word.writeByte(character, DoubleQuoted)
+		}
+	}
+}
+
+// readANSICQuoted adds the text of a $'...' string up to the closing single
+// quote to the word, decoding its backslash escapes. The result is taken
+// literally, as if it were single-quoted.
+func (p *Parser) readANSICQuoted(word *Word) {
+	opening := p.Index - 1 // The '$'
+	word.startPart(SingleQuoted)
+	for {
+		character := p.next()
+		if character == END {
+			p.markIncomplete(SINGLE, opening)
+			break
+		}
+		if character == SINGLE {
+			break
+		}
+		if character != BACKSLASH {
+			word.writeByte(character, SingleQuoted)
+			continue
+		}
+		decoded, consumed, _, _ := DecodeEscape(p.Input[p.Index+1:], EscapeModeANSIC)
+		for _, character := range []byte(decoded) {
+			word.writeByte(character, SingleQuoted)
 		}
+		p.Index += consumed
+	}
+}
+
+// handleDollar adds the parameter expansion or command substitution started
+// by a '$' to the word. Outside of double quotes, $'...' and $"..." are
+// quoted strings. A '$' that starts none of these is taken literally.
+func (p *Parser) handleDollar(word *Word, quoting Quoting) {
+	character := p.peek()
+	switch {
+	case character == SINGLE && quoting == Unquoted:
+		p.next()
+		p.readANSICQuoted(word)
+	case character == DOUBLE && quoting == Unquoted:
+		// Translating the string for the current locale is not supported, so
+		// it is the same as a double-quoted string.
+		p.next()
+		p.readDoubleQuoted(word)
+	case character == '{':
+		p.next()
+		start := p.Index + 1
+		for p.peek() != END && p.peek() != '}' {
+			p.next()
+		}
+		word.addExpansion(Parameter, p.Input[start:p.Index+1], quoting)
+		if p.next() == END {
+			p.markIncomplete('}', start-2)
+		}
+	case character == '(':
+		p.next()
+		word.addExpansion(CommandSubstitution, p.readCommandSubstitution(), quoting)
+	case isNameStart(character):
+		start := p.Index + 1
+		for isNameCharacter(p.peek()) {
+			p.next()
+		}
+		word.addExpansion(Parameter, p.Input[start:p.Index+1], quoting)
+	case isSpecialParameter(character):
+		p.next()
+		word.addExpansion(Parameter, string(character), quoting)
+	default:
+		word.writeByte(DOLLAR, quoting)
+	}
+}
+
+// ParseParameterWord parses the word of an expansion such as ${name:-word},
+// given the quoting the expansion appeared in. Blanks and operators are
+// ordinary characters in it, while quotes, backslashes and expansions keep
+// their meaning, except that single quotes are ordinary inside double quotes.
+func ParseParameterWord(text string, quoting Quoting) Word {
+	p := NewParser(text)
+	var word Word
+	for {
+		character := p.next()
+		switch {
+		case character == END:
+			return word
+		case character == BACKSLASH:
+			p.handleBackshalsh(&word, quoting == DoubleQuoted)
+		case character == DOLLAR:
+			p.handleDollar(&word, quoting)
+		case character == BACKQUOTE:
+			word.addExpansion(CommandSubstitution, p.readBackquoted(), quoting)
+		case character == SINGLE && quoting == Unquoted:
+			p.readSingleQuoted(&word)
+		case character == DOUBLE:
+			p.readDoubleQuoted(&word)
+		default:
+			word.writeByte(character, quoting)
+		}
+	}
+}
+
+// readCommandSubstitution returns the command of a $(...) substitution, or of
+// a <(...) or >(...) process substitution, and moves past its closing
+// parenthesis. Parentheses inside quotes are skipped.
+func (p *Parser) readCommandSubstitution() string {
+	start := p.Index + 1
+	depth := 1
+	var quote byte
+	for {
+		character := p.next()
+		switch {
+		case character == END:
+			p.markIncomplete(')', start-2)
+			return p.Input[start:]
+		case quote != 0:
+			if character == quote {
+				quote = 0
+			} else if character == BACKSLASH && quote == DOUBLE {
+				p.next()
+			}
+		case character == SINGLE || character == DOUBLE:
+			quote = character
+		case character == BACKSLASH:
+			p.next()
+		case character == '(':
+			depth++
+		case character == ')':
+			depth--
+			if depth == 0 {
+				return p.Input[start:p.Index]
+			}
+		}
+	}
+}
+
+// readBackquoted returns the command of a `...` substitution. A backslash
+// only escapes '$', '`' and another backslash, and is removed before them.
+func (p *Parser) readBackquoted() string {
+	opening := p.Index
+	var builder strings.Builder
+	for {
+		character := p.next()
+		if character == END {
+			p.markIncomplete(BACKQUOTE, opening)
+			return builder.String()
+		}
+		if character == BACKQUOTE {
+			return builder.String()
+		}
+		if character == BACKSLASH {
+			escaped := p.peek()
+			if escaped == DOLLAR || escaped == BACKQUOTE || escaped == BACKSLASH {
+				character = p.next()
+			}
+		}
+		builder.WriteByte(character)
+	}
+}
+
+func (p *Parser) peek() byte {
+	if p.Index+1 >= len(p.Input) {
+		return END
 	}
-	builder.WriteByte(character)
+	return p.Input[p.Index+1]
 }
 
 func (p *Parser) next() byte {
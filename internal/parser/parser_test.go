@@ -0,0 +1,289 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// parseList parses input, which must be a single and-or list, failing the
+// test on a syntax error.
+func parseList(t *testing.T, input string) AndOrList {
+	t.Helper()
+	p := NewParser(input)
+	lists, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse(%q) returned error: %v", input, err)
+	}
+	if len(lists) != 1 {
+		t.Fatalf("Parse(%q) = %d lists, want 1", input, len(lists))
+	}
+	return lists[0]
+}
+
+// parseCommands parses input, which must be a single pipeline, and returns
+// its commands.
+func parseCommands(t *testing.T, input string) Pipeline {
+	t.Helper()
+	list := parseList(t, input)
+	if len(list.Pipelines) != 1 {
+		t.Fatalf("Parse(%q) = %d pipelines, want 1", input, len(list.Pipelines))
+	}
+	return list.Pipelines[0]
+}
+
+func TestParseWordParts(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Word
+	}{
+		{`plain`, Word{{Text: "plain"}}},
+		{`'a b'`, Word{{Text: "a b", Quoting: SingleQuoted}}},
+		{`""`, Word{{Quoting: DoubleQuoted}}},
+		{`a\ b`, Word{{Text: "a"}, {Text: " ", Quoting: SingleQuoted}, {Text: "b"}}},
+		{`"a\"\$\x"`, Word{{Text: `a"$\x`, Quoting: DoubleQuoted}}},
+		{`$HOME/bin`, Word{{Text: "HOME", Kind: Parameter}, {Text: "/bin"}}},
+		{`${x}y`, Word{{Text: "x", Kind: Parameter}, {Text: "y"}}},
+		{`$?`, Word{{Text: "?", Kind: Parameter}}},
+		{`a$`, Word{{Text: "a$"}}},
+		{`"x=$x"`, Word{{Text: "x=", Quoting: DoubleQuoted}, {Text: "x", Quoting: DoubleQuoted, Kind: Parameter}}},
+		{`'$x'`, Word{{Text: "$x", Quoting: SingleQuoted}}},
+		{`$(echo "a)" (b))`, Word{{Text: `echo "a)" (b)`, Kind: CommandSubstitution}}},
+		{"`echo \\$x`", Word{{Text: "echo $x", Kind: CommandSubstitution}}},
+		{`"$(pwd)"`, Word{{Quoting: DoubleQuoted}, {Text: "pwd", Quoting: DoubleQuoted, Kind: CommandSubstitution}}},
+		{`café`, Word{{Text: "café"}}},
+	}
+	for _, test := range tests {
+		commands := parseCommands(t, test.input)
+		if len(commands) != 1 || len(commands[0].Args) != 1 {
+			t.Errorf("Parse(%q) = %v, want a single word", test.input, commands)
+			continue
+		}
+		if got := commands[0].Args[0]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) word = %#v, want %#v", test.input, got, test.want)
+		}
+	}
+}
+
+// argumentStrings returns the arguments of each command of pipeline with
+// their quotes removed.
+func argumentStrings(pipeline Pipeline) [][]string {
+	var commands [][]string
+	for _, command := range pipeline {
+		var args []string
+		for _, word := range command.Args {
+			args = append(args, word.String())
+		}
+		commands = append(commands, args)
+	}
+	return commands
+}
+
+func TestParseCommentsAndContinuation(t *testing.T) {
+	tests := []struct {
+		input string
+		want  [][]string
+	}{
+		{"echo a # comment", [][]string{{"echo", "a"}}},
+		{"echo a#b", [][]string{{"echo", "a#b"}}},
+		{"echo '#' \"#\" \\#", [][]string{{"echo", "#", "#", "#"}}},
+		{"echo a \\\nb", [][]string{{"echo", "a", "b"}}},
+		{"echo a\\\nb", [][]string{{"echo", "ab"}}},
+		{"echo \"a\\\nb\"", [][]string{{"echo", "ab"}}},
+		{"echo 'a\\\nb'", [][]string{{"echo", "a\\\nb"}}},
+		{"echo a |\ncat", [][]string{{"echo", "a"}, {"cat"}}},
+	}
+	for _, test := range tests {
+		got := argumentStrings(parseCommands(t, test.input))
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Parse(%q) = %q, want %q", test.input, got, test.want)
+		}
+	}
+}
+
+func TestParseCommentOnly(t *testing.T) {
+	p := NewParser("# nothing to run")
+	lists, err := p.Parse()
+	if err != nil || len(lists) != 0 {
+		t.Errorf("Parse of a comment = %v, %v, want nothing", lists, err)
+	}
+}
+
+func TestParseAndOrList(t *testing.T) {
+	list := parseList(t, "make && ./app | tee log || echo failed")
+	var got [][][]string
+	for _, pipeline := range list.Pipelines {
+		got = append(got, argumentStrings(pipeline))
+	}
+	want := [][][]string{{{"make"}}, {{"./app"}, {"tee", "log"}}, {{"echo", "failed"}}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("pipelines = %q, want %q", got, want)
+	}
+	if wantOperators := []string{"&&", "||"}; !reflect.DeepEqual(list.Operators, wantOperators) {
+		t.Errorf("operators = %q, want %q", list.Operators, wantOperators)
+	}
+}
+
+func TestParseQuotedOperatorIsAWord(t *testing.T) {
+	got := argumentStrings(parseCommands(t, `echo '&&' "||" \|`))
+	if want := [][]string{{"echo", "&&", "||", "|"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %q, want %q", got, want)
+	}
+}
+
+func TestParseIncomplete(t *testing.T) {
+	for _, input := range []string{"echo 'a", `echo "a`, "echo a \\", "echo a |", "true &&", "false ||", "true && false |"} {
+		p := NewParser(input)
+		if _, err := p.Parse(); !IsIncomplete(err) {
+			t.Errorf("Parse(%q) error = %v, want an incomplete command", input, err)
+		}
+	}
+}
+
+func TestParseMisplacedOperator(t *testing.T) {
+	for _, input := range []string{"| cat", "&& true", "true || || false", "true | && false"} {
+		p := NewParser(input)
+		_, err := p.Parse()
+		if err == nil || IsIncomplete(err) {
+			t.Errorf("Parse(%q) error = %v, want a syntax error", input, err)
+		}
+	}
+}
+
+func TestParseCommandLists(t *testing.T) {
+	p := NewParser("echo a;echo\tb\n\ntrue&&echo c|cat\r\n")
+	lists, err := p.Parse()
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	var got [][][][]string
+	for _, list := range lists {
+		var pipelines [][][]string
+		for _, pipeline := range list.Pipelines {
+			pipelines = append(pipelines, argumentStrings(pipeline))
+		}
+		got = append(got, pipelines)
+	}
+	want := [][][][]string{
+		{{{"echo", "a"}}},
+		{{{"echo", "b"}}},
+		{{{"true"}}, {{"echo", "c"}, {"cat"}}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %q, want %q", got, want)
+	}
+}
+
+func TestParseExtendedPatterns(t *testing.T) {
+	p := NewParser("ls @(a|b);echo")
+	p.ExtendedPatterns = true
+	lists, err := p.Parse()
+	if err != nil || len(lists) != 2 {
+		t.Fatalf("Parse with extended patterns = %v, %v, want two lists", lists, err)
+	}
+	if got := argumentStrings(lists[0].Pipelines[0]); !reflect.DeepEqual(got, [][]string{{"ls", "@(a|b)"}}) {
+		t.Errorf("first list = %q, want the pattern as one word", got)
+	}
+
+	if got := argumentStrings(parseCommands(t, "ls @(a|b)")); !reflect.DeepEqual(got, [][]string{{"ls", "@(a"}, {"b)"}}) {
+		t.Errorf("Parse without extended patterns = %q, want a pipeline", got)
+	}
+}
+
+func TestSyntaxErrorPosition(t *testing.T) {
+	tests := []struct {
+		input        string
+		wantMessage  string
+		wantLine     int
+		wantColumn   int
+		wantSnippet  string
+		isIncomplete bool
+	}{
+		{"echo a ; ; echo b", "syntax error near unexpected token `;'", 1, 10, "echo a ; ; echo b\n         ^\n", false},
+		{"echo ok\n\t| cat", "syntax error near unexpected token `|'", 2, 2, "\t| cat\n\t^\n", false},
+		{"echo é >", "syntax error near unexpected token `newline'", 1, 9, "echo é >\n        ^\n", false},
+		{"echo 'open\nmore", "unexpected EOF while looking for matching `''", 1, 6, "echo 'open\n     ^\n", true},
+		{"echo a |", "syntax error: unexpected end of file", 1, 9, "echo a |\n        ^\n", true},
+	}
+	for _, test := range tests {
+		p := NewParser(test.input)
+		_, err := p.Parse()
+		var syntaxError *SyntaxError
+		if !errors.As(err, &syntaxError) {
+			t.Errorf("Parse(%q) error = %v, want a SyntaxError", test.input, err)
+			continue
+		}
+		if syntaxError.Message != test.wantMessage {
+			t.Errorf("Parse(%q) message = %q, want %q", test.input, syntaxError.Message, test.wantMessage)
+		}
+		if syntaxError.Line != test.wantLine || syntaxError.Column != test.wantColumn {
+			t.Errorf("Parse(%q) position = %d:%d, want %d:%d",
+				test.input, syntaxError.Line, syntaxError.Column, test.wantLine, test.wantColumn)
+		}
+		if snippet := syntaxError.Snippet(); snippet != test.wantSnippet {
+			t.Errorf("Parse(%q) snippet = %q, want %q", test.input, snippet, test.wantSnippet)
+		}
+		if syntaxError.IsIncomplete != test.isIncomplete {
+			t.Errorf("Parse(%q) IsIncomplete = %v, want %v", test.input, syntaxError.IsIncomplete, test.isIncomplete)
+		}
+	}
+}
+
+func TestParseCoprocess(t *testing.T) {
+	tests := []struct {
+		input    string
+		name     string
+		body     [][][]string
+		bodyText string
+		text     string
+	}{
+		{"coproc cat", "COPROC", [][][]string{{{"cat"}}}, "cat", "coproc cat"},
+		{"coproc sort -r | uniq\n", "COPROC", [][][]string{{{"sort", "-r"}, {"uniq"}}}, "sort -r | uniq", "coproc sort -r | uniq"},
+		{"coproc { cat; }", "COPROC", [][][]string{{{"cat"}}}, "cat;", "coproc { cat; }"},
+		{"coproc NAME { read x; echo x && true; }", "NAME", [][][]string{{{"read", "x"}}, {{"echo", "x"}}, {{"true"}}}, "read x; echo x && true;", "coproc NAME { read x; echo x && true; }"},
+		{"coproc NAME {\n\tcat\n}", "NAME", [][][]string{{{"cat"}}}, "cat", "coproc NAME {\n\tcat\n}"},
+	}
+	for _, test := range tests {
+		list := parseList(t, test.input)
+		if len(list.Pipelines) != 1 || len(list.Pipelines[0]) != 1 || list.Pipelines[0][0].Coprocess == nil {
+			t.Errorf("Parse(%q) is not a coprocess", test.input)
+			continue
+		}
+		coprocess := list.Pipelines[0][0].Coprocess
+		var body [][][]string
+		for _, bodyList := range coprocess.Body {
+			for _, pipeline := range bodyList.Pipelines {
+				body = append(body, argumentStrings(pipeline))
+			}
+		}
+		if coprocess.Name != test.name || !reflect.DeepEqual(body, test.body) {
+			t.Errorf("Parse(%q) = coprocess %s with body %q, want %s with %q", test.input, coprocess.Name, body, test.name, test.body)
+		}
+		if coprocess.BodyText != test.bodyText || coprocess.Text != test.text {
+			t.Errorf("Parse(%q) = body text %q and text %q, want %q and %q", test.input, coprocess.BodyText, coprocess.Text, test.bodyText, test.text)
+		}
+	}
+}
+
+func TestParseCoprocessWordIsNotAKeyword(t *testing.T) {
+	got := argumentStrings(parseCommands(t, "echo coproc { x }"))
+	if want := [][]string{{"echo", "coproc", "{", "x", "}"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse = %q, want %q", got, want)
+	}
+}
+
+func TestParseCoprocessErrors(t *testing.T) {
+	for _, input := range []string{"coproc", "coproc cat |", "coproc NAME { cat", "coproc { true &&"} {
+		p := NewParser(input)
+		if _, err := p.Parse(); !IsIncomplete(err) {
+			t.Errorf("Parse(%q) error = %v, want an incomplete command", input, err)
+		}
+	}
+	for _, input := range []string{"coproc { }", "coproc NAME { cat; } extra", "coproc { cat; } | cat", "coproc { true && }"} {
+		p := NewParser(input)
+		_, err := p.Parse()
+		if err == nil || IsIncomplete(err) {
+			t.Errorf("Parse(%q) error = %v, want a syntax error", input, err)
+		}
+	}
+}
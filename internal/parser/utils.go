@@ -1,13 +1,68 @@
 package parser
 
+import "strings"
+
 func mapBackshlash(character byte) byte {
-	if character == DOUBLE || character == BACKSLASH {
+	if character == DOUBLE || character == BACKSLASH || character == DOLLAR || character == BACKQUOTE {
 		return character
 	}
 	return END
 }
 
+// isRedirectionOperator reports whether operator is one of '<', '>', ">>",
+// "<&" and ">&", optionally prefixed with a descriptor number.
 func isRedirectionOperator(operator string) bool {
-	return (operator == ">") || (operator == "1>") || (operator == "2>") ||
-		(operator == ">>") || (operator == "1>>") || (operator == "2>>")
+	operator = strings.TrimLeft(operator, "0123456789")
+	return operator == "<" || operator == ">" || operator == ">>" || operator == "<&" || operator == ">&"
+}
+
+// isDescriptorNumber reports whether text is a descriptor number that may
+// prefix a redirection operator.
+func isDescriptorNumber(text string) bool {
+	return len(text) > 0 && strings.Trim(text, "0123456789") == ""
+}
+
+func isNameStart(character byte) bool {
+	return (character >= 'a' && character <= 'z') || (character >= 'A' && character <= 'Z') || character == '_'
+}
+
+func isNameCharacter(character byte) bool {
+	return isNameStart(character) || (character >= '0' && character <= '9')
+}
+
+// isSpecialParameter reports whether $character is a special or positional
+// parameter, whose name is a single character.
+func isSpecialParameter(character byte) bool {
+	return (character >= '0' && character <= '9') || strings.IndexByte("?$#!@*-", character) >= 0
+}
+
+// isBlank reports whether character separates words.
+func isBlank(character byte) bool {
+	return character == SPACE || character == TAB
+}
+
+// isOperatorCharacter reports whether character starts an operator, which
+// ends the word before it.
+func isOperatorCharacter(character byte) bool {
+	return character == PIPE || character == AMPERSAND || character == SEMICOLON || character == NEWLINE || character == '<' || character == '>'
+}
+
+func isTerminator(token string) bool {
+	return token == string(SEMICOLON) || token == string(NEWLINE)
+}
+
+func isOperator(token string) bool {
+	return token == string(PIPE) || isAndOrOperator(token) || isTerminator(token)
+}
+
+// isAndOrOperator reports whether token joins the pipelines of an and-or
+// list.
+func isAndOrOperator(token string) bool {
+	return token == "&&" || token == "||"
+}
+
+// IsKeyword reports whether word is a reserved word, which is recognized
+// when it appears unquoted where a command starts.
+func IsKeyword(word string) bool {
+	return word == "coproc"
 }
@@ -0,0 +1,74 @@
+package parser
+
+import "strings"
+
+// Quoting describes how a part of a word was quoted in the input.
+type Quoting int
+
+const (
+	Unquoted     Quoting = iota
+	SingleQuoted         // Inside single quotes or escaped with a backslash
+	DoubleQuoted         // Inside double quotes
+)
+
+// PartKind tells literal text apart from the expansions of a word.
+type PartKind int
+
+const (
+	Literal                   PartKind = iota
+	Parameter                          // $name or ${name}; Text is the name
+	CommandSubstitution                // $(command) or `command`; Text is the command
+	InputProcessSubstitution           // <(command); Text is the command
+	OutputProcessSubstitution          // >(command); Text is the command
+)
+
+// WordPart is a run of characters of a word that were quoted the same way,
+// or a single expansion along with the quoting it appeared in.
+type WordPart struct {
+	Text    string
+	Quoting Quoting
+	Kind    PartKind
+}
+
+// Word is an argument as written in the input. Keeping track of how each
+// part was quoted lets expansions apply only to the unquoted parts.
+type Word []WordPart
+
+// String returns the text of the word with its quotes removed.
+func (w Word) String() string {
+	var builder strings.Builder
+	for _, part := range w {
+		builder.WriteString(part.Text)
+	}
+	return builder.String()
+}
+
+// UnquotedText returns the text of the word and whether none of it was
+// quoted or expanded. Operators are only recognized in unquoted words.
+func (w Word) UnquotedText() (string, bool) {
+	for _, part := range w {
+		if part.Quoting != Unquoted || part.Kind != Literal {
+			return w.String(), false
+		}
+	}
+	return w.String(), true
+}
+
+// startPart begins a new part, so that quotes with nothing between them
+// still produce an (empty) argument.
+func (w *Word) startPart(quoting Quoting) {
+	*w = append(*w, WordPart{Quoting: quoting})
+}
+
+func (w *Word) writeByte(character byte, quoting Quoting) {
+	last := len(*w) - 1
+	if last >= 0 && (*w)[last].Quoting == quoting && (*w)[last].Kind == Literal {
+		(*w)[last].Text += string([]byte{character})
+		return
+	}
+	*w = append(*w, WordPart{Text: string([]byte{character}), Quoting: quoting})
+}
+
+func (w *Word) addExpansion(kind PartKind, text string, quoting Quoting) {
+	*w = append(*w, WordPart{Text: text, Quoting: quoting, Kind: kind})
+}
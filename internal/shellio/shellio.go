@@ -3,6 +3,8 @@ package shellio
 import (
 	"fmt"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -10,76 +12,300 @@ import (
 type RedirectionConfig struct {
 	File            string
 	Descriptor      int
+	IsInput         bool
 	IsAppendEnabled bool
+	// IsDuplicate is set for "N>&M" and "N<&M", where File is the descriptor
+	// M to copy, or "-" to close descriptor N.
+	IsDuplicate bool
 }
 
-func NewRedirectionConfig(operator string, file string) RedirectionConfig {
-	descriptor := 1
-	append := false
-	if strings.HasPrefix(operator, "2") {
-		descriptor = 2
+// NewRedirectionConfig returns the configuration of a redirection operator,
+// such as ">", "2>>" or "3<&", applied to target. The descriptor defaults to
+// standard input for '<' and standard output for '>'.
+func NewRedirectionConfig(operator string, target string) RedirectionConfig {
+	symbol := strings.TrimLeft(operator, "0123456789")
+	config := RedirectionConfig{
+		File:            target,
+		Descriptor:      1,
+		IsInput:         strings.HasPrefix(symbol, "<"),
+		IsAppendEnabled: symbol == ">>",
+		IsDuplicate:     strings.HasSuffix(symbol, "&"),
 	}
-	if strings.Contains(operator, ">>") {
-		append = true
+	if config.IsInput {
+		config.Descriptor = 0
 	}
-	return RedirectionConfig{
-		File:            file,
-		Descriptor:      descriptor,
-		IsAppendEnabled: append,
+	if descriptor, err := strconv.Atoi(operator[:len(operator)-len(symbol)]); err == nil {
+		config.Descriptor = descriptor
 	}
+	return config
 }
 
+// maxDescriptor is the highest descriptor a redirection may use.
+const maxDescriptor = 1023
+
+// IO is the set of files a command runs with. Besides the standard streams,
+// any other descriptor may refer to a file, as after "exec 3>log".
 type IO interface {
+	InputFile() *os.File
 	OutputFile() *os.File
 	ErrorFile() *os.File
+	// File returns the file descriptor refers to, or nil if it is closed.
+	File(descriptor int) *os.File
+	// Descriptors returns the open descriptors in increasing order.
+	Descriptors() []int
+	// Redirected returns the descriptors changed by the redirections of this
+	// IO, rather than inherited from its parent, in increasing order.
+	Redirected() []int
 	Close()
 }
 
-func NewIO(outputFile, errorFile *os.File) IO {
-	return &FileRedirect{
-		outputFile: outputFile,
-		errorFile:  errorFile,
+func NewIO(inputFile, outputFile, errorFile *os.File) IO {
+	io := &FileRedirect{}
+	for descriptor, file := range []*os.File{inputFile, outputFile, errorFile} {
+		if file != nil {
+			io.setFile(descriptor, file)
+		}
+	}
+	return io
+}
+
+// WithFile returns an IO with the files of parent, except that descriptor
+// refers to file. The file is not closed along with the returned IO.
+func WithFile(parent IO, descriptor int, file *os.File) IO {
+	io := inherit(parent)
+	io.setFile(descriptor, file)
+	return io
+}
+
+// Overlay returns an IO whose descriptors refer to the files of base, which
+// are looked up each time they are used, except for the descriptors in
+// files, which refer to the given files or are closed if nil. Commands run
+// with it see the changes made to base while they run.
+func Overlay(base IO, files map[int]*os.File) IO {
+	return &overlayIO{base: base, files: files}
+}
+
+type overlayIO struct {
+	base  IO
+	files map[int]*os.File
+}
+
+func (io *overlayIO) InputFile() *os.File {
+	return io.File(0)
+}
+
+func (io *overlayIO) OutputFile() *os.File {
+	return io.File(1)
+}
+
+func (io *overlayIO) ErrorFile() *os.File {
+	return io.File(2)
+}
+
+func (io *overlayIO) File(descriptor int) *os.File {
+	if file, ok := io.files[descriptor]; ok {
+		return file
+	}
+	return io.base.File(descriptor)
+}
+
+func (io *overlayIO) Descriptors() []int {
+	var descriptors []int
+	for _, descriptor := range io.base.Descriptors() {
+		if _, ok := io.files[descriptor]; !ok {
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	for descriptor, file := range io.files {
+		if file != nil {
+			descriptors = append(descriptors, descriptor)
+		}
 	}
+	slices.Sort(descriptors)
+	return descriptors
+}
+
+// Redirected returns nothing, as an overlay has no redirections of its own.
+func (io *overlayIO) Redirected() []int {
+	return nil
 }
 
+// Close does nothing, as the files belong to base and the caller.
+func (io *overlayIO) Close() {}
+
+// FileRedirect is an IO made of the standard streams of the shell process,
+// with some descriptors replaced. Its zero value refers to the standard
+// streams only.
 type FileRedirect struct {
-	outputFile *os.File
-	errorFile  *os.File
+	// files holds the descriptors that differ from the standard streams,
+	// with nil marking a closed descriptor.
+	files       map[int]*os.File
+	redirected  []int
+	openedFiles []*os.File
+}
+
+// InputFile returns the input file. If it is not set, it returns os.Stdin.
+func (io *FileRedirect) InputFile() *os.File {
+	return io.File(0)
 }
 
 // OutputFile returns the output file. If it is not set, it returns os.Stdout.
 func (io *FileRedirect) OutputFile() *os.File {
-	if io.outputFile != nil {
-		return io.outputFile
-	} else {
-		return os.Stdout
-	}
+	return io.File(1)
 }
 
 // ErrorFile returns the error file. If it is not set, it returns os.Stderr.
 func (io *FileRedirect) ErrorFile() *os.File {
-	if io.errorFile != nil {
-		return io.errorFile
-	} else {
+	return io.File(2)
+}
+
+func (io *FileRedirect) File(descriptor int) *os.File {
+	if file, ok := io.files[descriptor]; ok {
+		return file
+	}
+	switch descriptor {
+	case 0:
+		return os.Stdin
+	case 1:
+		return os.Stdout
+	case 2:
 		return os.Stderr
 	}
+	return nil
+}
+
+func (io *FileRedirect) Descriptors() []int {
+	var descriptors []int
+	for descriptor := range 3 {
+		if io.File(descriptor) != nil {
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	for descriptor, file := range io.files {
+		if descriptor > 2 && file != nil {
+			descriptors = append(descriptors, descriptor)
+		}
+	}
+	slices.Sort(descriptors)
+	return descriptors
+}
+
+func (io *FileRedirect) Redirected() []int {
+	return slices.Sorted(slices.Values(io.redirected))
 }
 
-// Close closes any files that were opened for redirection.
+// SetFile makes descriptor refer to file from now on, or closes it if file
+// is nil. The file is owned by io and closed along with it, or once the
+// descriptor is changed again.
+func (io *FileRedirect) SetFile(descriptor int, file *os.File) {
+	io.setFile(descriptor, file)
+	if file != nil {
+		io.openedFiles = append(io.openedFiles, file)
+	}
+}
+
+// setFile makes descriptor refer to file, closing the file it referred to
+// if io owned it.
+func (io *FileRedirect) setFile(descriptor int, file *os.File) {
+	if previous := io.files[descriptor]; previous != nil {
+		if index := slices.Index(io.openedFiles, previous); index >= 0 && previous != file {
+			io.openedFiles = slices.Delete(io.openedFiles, index, index+1)
+			previous.Close()
+		}
+	}
+	if io.files == nil {
+		io.files = make(map[int]*os.File)
+	}
+	io.files[descriptor] = file
+}
+
+// Close closes any files that were opened for redirection. Files handed in
+// through NewIO are owned by the caller and are left open.
 func (io *FileRedirect) Close() {
-	if io.outputFile != nil {
-		io.outputFile.Close()
-		io.outputFile = nil
+	for _, file := range io.openedFiles {
+		file.Close()
+	}
+	io.openedFiles = nil
+}
+
+// inherit returns an IO whose descriptors refer to the same files as those
+// of parent, without owning any of them.
+func inherit(parent IO) *FileRedirect {
+	io := &FileRedirect{}
+	for descriptor := range 3 {
+		io.setFile(descriptor, parent.File(descriptor))
+	}
+	for _, descriptor := range parent.Descriptors() {
+		if descriptor > 2 {
+			io.setFile(descriptor, parent.File(descriptor))
+		}
+	}
+	return io
+}
+
+// OpenIo applies the redirections in order on top of the files of parent
+// and returns the resulting IO. If a file cannot be opened, the files opened
+// so far are closed and the error is returned.
+func OpenIo(redirects []RedirectionConfig, parent IO) (IO, error) {
+	io := inherit(parent)
+
+	for _, redirect := range redirects {
+		if redirect.Descriptor > maxDescriptor {
+			io.Close()
+			return nil, fmt.Errorf("%d: bad file descriptor", redirect.Descriptor)
+		}
+
+		var file *os.File
+		if redirect.IsDuplicate {
+			var err error
+			file, err = io.duplicate(redirect)
+			if err != nil {
+				io.Close()
+				return nil, err
+			}
+		} else {
+			var err error
+			file, err = openRedirectionFile(redirect)
+			if err != nil {
+				io.Close()
+				return nil, err
+			}
+			io.openedFiles = append(io.openedFiles, file)
+		}
+
+		io.setFile(redirect.Descriptor, file)
+		if !slices.Contains(io.redirected, redirect.Descriptor) {
+			io.redirected = append(io.redirected, redirect.Descriptor)
+		}
+	}
+
+	return io, nil
+}
+
+// duplicate returns the file that the descriptor named by a "N>&M" or "N<&M"
+// redirection refers to, or nil for "N>&-".
+func (io *FileRedirect) duplicate(redirect RedirectionConfig) (*os.File, error) {
+	if redirect.File == "-" {
+		return nil, nil
 	}
-	if io.errorFile != nil {
-		io.errorFile.Close()
-		io.errorFile = nil
+	descriptor, err := strconv.Atoi(redirect.File)
+	if err != nil || descriptor < 0 {
+		return nil, fmt.Errorf("%s: ambiguous redirect", redirect.File)
 	}
+	file := io.File(descriptor)
+	if file == nil {
+		return nil, fmt.Errorf("%d: bad file descriptor", descriptor)
+	}
+	return file, nil
 }
 
-func OpenIo(redirect RedirectionConfig) (IO, bool) {
-	if redirect.File == "" {
-		return &FileRedirect{}, false
+func openRedirectionFile(redirect RedirectionConfig) (*os.File, error) {
+	if redirect.IsInput {
+		file, err := os.Open(redirect.File)
+		if err != nil {
+			return nil, &RedirectionError{File: redirect.File, Err: err}
+		}
+		return file, nil
 	}
 
 	flag := os.O_CREATE | os.O_WRONLY
@@ -91,13 +317,23 @@ func OpenIo(redirect RedirectionConfig) (IO, bool) {
 
 	file, err := os.OpenFile(redirect.File, flag, 0664)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "shell: %s: %s\n", redirect.File, err.Error())
-		return &FileRedirect{}, false
+		return nil, &RedirectionError{File: redirect.File, Err: err}
 	}
+	return file, nil
+}
 
-	if redirect.Descriptor == 1 {
-		return &FileRedirect{outputFile: file, errorFile: nil}, true
-	} else {
-		return &FileRedirect{outputFile: nil, errorFile: file}, true
-	}
+// RedirectionError is a redirection whose file could not be opened. Err is
+// the error of the file operation, which the shell describes like those of
+// its builtins.
+type RedirectionError struct {
+	File string
+	Err  error
+}
+
+func (e *RedirectionError) Error() string {
+	return e.File + ": " + e.Err.Error()
+}
+
+func (e *RedirectionError) Unwrap() error {
+	return e.Err
 }